@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ageBuckets — границы возрастных групп строк кода (возраст меньше границы).
+// Строки старше последней границы попадают в группу «старше».
+var ageBuckets = []struct {
+	title string
+	limit time.Duration
+}{
+	{"< 3 мес", 90 * 24 * time.Hour},
+	{"3–12 мес", 365 * 24 * time.Hour},
+	{"1–3 года", 3 * 365 * 24 * time.Hour},
+}
+
+const ageSynopsis = "loc_counter age [флаги] [директория]"
+
+// ageFlags объявляет флаги подкоманды age; фильтры путей попадают в opts.
+func ageFlags(fs *flag.FlagSet, opts *options) {
+	fs.Var(&opts.exclude, "exclude", "Директории для исключения (например, --exclude vendor).")
+	fs.Var(&opts.ext, "ext", "Расширения для включения. По умолчанию: все поддерживаемые.")
+	fs.Var(&opts.extExclude, "ext-exclude", "Расширения для исключения.")
+	fs.Var(&opts.excludeFiles, "exclude-file", "Шаблоны файлов для исключения (например, --exclude-file '*.pb.go').")
+}
+
+// runAge реализует подкоманду age: распределяет строки кода,
+// отслеживаемые git, по возрасту их последнего изменения (git blame).
+func runAge(args []string) {
+	fs := flag.NewFlagSet("age", flag.ExitOnError)
+	var opts options
+	ageFlags(fs, &opts)
+	fs.Usage = commandUsage(fs, ageSynopsis)
+	lang := langFlag(fs)
+	fs.Parse(args)
+	setLocale(*lang)
+
+	dir := "."
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+
+	out, err := runGit(dir, "ls-files", "-z")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+		os.Exit(1)
+	}
+
+	type job struct {
+		path string
+		cfg  LangConfig
+	}
+	// Пути git ls-files — относительно dir и со слешами, как в фильтрах
+	var jobs []job
+	for _, path := range splitNul(out) {
+		if opts.excludedPath(path) || opts.excludedFile(path) || !opts.acceptExt(strings.ToLower(filepath.Ext(path))) {
+			continue
+		}
+		if cfg, ok := languageByName(filepath.Base(path)); ok {
+			jobs = append(jobs, job{path, cfg})
+		}
+	}
+
+	// Язык -> количество строк кода в каждой возрастной группе
+	// (последний элемент — строки старше всех границ)
+	byLang := make(map[string][]int)
+	var mu sync.Mutex
+	now := time.Now()
+
+	// git blame работает медленно, поэтому файлы обрабатываются параллельно
+	queue := make(chan job)
+	var wg sync.WaitGroup
+	for range runtime.NumCPU() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				counts, err := blameAges(dir, j.path, j.cfg, now)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, tr("предупреждение: %s: %v\n"), escapeName(j.path), err)
+					continue
+				}
+				mu.Lock()
+				if byLang[j.cfg.Name] == nil {
+					byLang[j.cfg.Name] = make([]int, len(ageBuckets)+1)
+				}
+				for i, n := range counts {
+					byLang[j.cfg.Name][i] += n
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, j := range jobs {
+		queue <- j
+	}
+	close(queue)
+	wg.Wait()
+
+	if len(byLang) == 0 {
+		fmt.Println(tr("Поддерживаемые исходные файлы не найдены."))
+		return
+	}
+
+	printAgeTable(byLang)
+}
+
+// blameAges возвращает число строк кода файла в каждой возрастной группе.
+// Возраст строки — время авторства коммита, последним изменившего её;
+// незакоммиченные строки git blame датирует текущим моментом.
+func blameAges(dir, path string, cfg LangConfig, now time.Time) ([]int, error) {
+	out, err := runGit(dir, "blame", "--line-porcelain", "--", path)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]int, len(ageBuckets)+1)
+	classifier := lineClassifier{cfg: cfg}
+	var authorTime time.Time
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Строка содержимого файла в формате porcelain начинается с табуляции,
+		// все остальные строки — заголовки коммита
+		if content, ok := strings.CutPrefix(line, "\t"); ok {
+			if classifier.classify(content) == lineCode {
+				counts[ageBucket(now.Sub(authorTime))]++
+			}
+			continue
+		}
+		if v, ok := strings.CutPrefix(line, "author-time "); ok {
+			if sec, err := strconv.ParseInt(v, 10, 64); err == nil {
+				authorTime = time.Unix(sec, 0)
+			}
+		}
+	}
+	return counts, scanner.Err()
+}
+
+func ageBucket(age time.Duration) int {
+	for i, b := range ageBuckets {
+		if age < b.limit {
+			return i
+		}
+	}
+	return len(ageBuckets)
+}
+
+func printAgeTable(byLang map[string][]int) {
+	langs := make([]string, 0, len(byLang))
+	for lang := range byLang {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	headers := []string{tr("Язык")}
+	for _, b := range ageBuckets {
+		headers = append(headers, tr(b.title))
+	}
+	headers = append(headers, tr("старше"), tr("Всего"))
+
+	total := make([]int, len(ageBuckets)+1)
+	rows := make([][]string, 0, len(langs)+1)
+	for _, lang := range langs {
+		row := []string{lang}
+		sum := 0
+		for i, n := range byLang[lang] {
+			row = append(row, strconv.Itoa(n))
+			total[i] += n
+			sum += n
+		}
+		rows = append(rows, append(row, strconv.Itoa(sum)))
+	}
+	totalRow := []string{tr("Итого")}
+	sum := 0
+	for _, n := range total {
+		totalRow = append(totalRow, strconv.Itoa(n))
+		sum += n
+	}
+	totalRow = append(totalRow, strconv.Itoa(sum))
+
+	fmt.Println()
+	printTable(headers, rows, totalRow)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+)
+
+// asmDialects — синтаксис комментариев ассемблеров. Один и тот же символ
+// в разных диалектах значит разное: «;» в GNU as для x86 разделяет
+// инструкции, а в NASM начинает комментарий; «#» в NASM — обычный символ.
+var asmDialects = map[string]LangConfig{
+	// GNU as для x86 (AT&T); в .S до ассемблера работает препроцессор C,
+	// и его директивы — код, а не комментарии «#»
+	"gas": {Name: "Assembly", SingleLine: []string{"#", "//"}, MultiStart: "/*", MultiEnd: "*/", Strings: []string{`"`}, MultilineStrings: cppDirectives(), Imports: []string{".include", "#include"}},
+	// NASM, MASM, FASM
+	"nasm": {Name: "Assembly", SingleLine: []string{";"}, Strings: []string{`"`, `'`, "`"}, Imports: []string{"%include", "include "}},
+	// GNU as для 32-битного ARM
+	"arm": {Name: "Assembly", SingleLine: []string{"@", "//"}, MultiStart: "/*", MultiEnd: "*/", Strings: []string{`"`}, Imports: []string{".include", "#include"}},
+	// GNU as для AArch64
+	"aarch64": {Name: "Assembly", SingleLine: []string{"//"}, MultiStart: "/*", MultiEnd: "*/", Strings: []string{`"`}, Imports: []string{".include", "#include"}},
+}
+
+// cppDirectives возвращает директивы препроцессора C как литералы без
+// конца: так «#» в их начале не считается комментарием.
+func cppDirectives() []multilineString {
+	var literals []multilineString
+	for _, d := range []string{"include", "define", "undef", "if", "elif", "else", "endif", "pragma", "error"} {
+		literals = append(literals, multilineString{Start: "#" + d})
+	}
+	return literals
+}
+
+// asmDefaults — диалект по расширению, если --asm-syntax auto не нашёл
+// в файле комментариев.
+var asmDefaults = map[string]string{
+	".s":   "gas",
+	".asm": "nasm",
+}
+
+// checkAsmSyntax проверяет значение --asm-syntax.
+func checkAsmSyntax(syntax string) error {
+	if _, ok := asmDialects[syntax]; ok || syntax == "auto" {
+		return nil
+	}
+	return fmt.Errorf(tr("неизвестный синтаксис ассемблера --asm-syntax %q (ожидается auto, gas, nasm, arm или aarch64)"), syntax)
+}
+
+// asmLanguage возвращает конфигурацию ассемблера для файла с расширением
+// ext: диалект из --asm-syntax или, при auto, найденный по содержимому.
+func (o *options) asmLanguage(ext string, head func() []byte) LangConfig {
+	syntax := o.asmSyntax
+	if syntax == "" || syntax == "auto" {
+		syntax = asmDefaults[ext]
+		if head != nil {
+			if sniffed := sniffAsmSyntax(head()); sniffed != "" {
+				syntax = sniffed
+			}
+		}
+	}
+	return asmDialects[syntax]
+}
+
+// cppDirective — директива препроцессора C в файле .S, а не комментарий «#».
+var cppDirective = regexp.MustCompile(`^#\s*(include|define|undef|if|ifdef|ifndef|elif|else|endif|pragma|error)\b`)
+
+// sniffAsmSyntax определяет диалект по тому, с какого символа чаще
+// начинаются строки-комментарии. При равенстве возвращает пустую строку.
+func sniffAsmSyntax(head []byte) string {
+	votes := make(map[string]int)
+	for _, line := range bytes.Split(head, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		switch {
+		case len(line) == 0:
+		case line[0] == ';':
+			votes["nasm"]++
+		case line[0] == '@':
+			votes["arm"]++
+		case bytes.HasPrefix(line, []byte("//")):
+			votes["aarch64"]++
+		case line[0] == '#' && !cppDirective.Match(line):
+			votes["gas"]++
+		}
+	}
+	best, bestVotes, tie := "", 0, false
+	for _, syntax := range []string{"gas", "nasm", "arm", "aarch64"} {
+		switch n := votes[syntax]; {
+		case n > bestVotes:
+			best, bestVotes, tie = syntax, n, false
+		case n == bestVotes && n > 0:
+			tie = true
+		}
+	}
+	if tie {
+		return ""
+	}
+	return best
+}
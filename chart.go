@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// chartBar — одна полоса диаграммы.
+type chartBar struct {
+	label string
+	value int
+}
+
+// chartColors — цвета полос, используются по кругу.
+var chartColors = []string{
+	"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f",
+	"#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac",
+}
+
+// Размеры диаграммы в пикселях.
+const (
+	chartLabelWidth = 160
+	chartBarWidth   = 420
+	chartValueWidth = 140
+	chartRowHeight  = 26
+	chartTitleSpace = 40
+)
+
+// Размеры диаграммы в терминале в символах.
+const (
+	termBarWidth   = 40
+	termLabelWidth = 16
+)
+
+// languageBars возвращает полосы диаграммы строк по языкам.
+func (r *report) languageBars() []chartBar {
+	langs, totals := r.byLanguage()
+	bars := make([]chartBar, 0, len(langs))
+	for _, lang := range langs {
+		bars = append(bars, chartBar{lang, totals[lang].lines})
+	}
+	return bars
+}
+
+// printTerminalChart выводит горизонтальную диаграмму полос с долями,
+// как сводка языков github-linguist. Полосы рисуются блоками Unicode
+// с точностью до 1/8 символа и окрашиваются по языку, если включены цвета.
+func printTerminalChart(bars []chartBar, total int) {
+	const eighths = " ▏▎▍▌▋▊▉"
+	blocks := []rune(eighths)
+
+	labelWidth := 0
+	for _, b := range bars {
+		labelWidth = max(labelWidth, min(visibleLen(b.label), termLabelWidth))
+	}
+	for _, b := range bars {
+		label := []rune(b.label)
+		if len(label) > termLabelWidth {
+			label = append(label[:termLabelWidth-1], '…')
+		}
+
+		units := 0
+		if total > 0 {
+			units = int(math.Round(float64(b.value) * termBarWidth * 8 / float64(total)))
+		}
+		bar := strings.Repeat("█", units/8)
+		if units%8 > 0 {
+			bar += string(blocks[units%8])
+		}
+		pad := strings.Repeat(" ", termBarWidth-len([]rune(bar)))
+
+		fmt.Printf("%-*s  %s%s  %6s  %d\n", labelWidth, string(label),
+			paint(languageColor(b.label), bar), pad, formatShare(b.value, total), b.value)
+	}
+	fmt.Println()
+}
+
+// writeChart сохраняет горизонтальную столбчатую диаграмму в файл path.
+// Поддерживается формат SVG: он не требует внешних библиотек и встраивается
+// в вики и HTML-отчёты как есть.
+func writeChart(path, title string, bars []chartBar) error {
+	if err := checkChartPath(path); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeBarChartSVG(f, title, bars); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// checkChartPath проверяет, что формат диаграммы по расширению файла
+// поддерживается. Вызывается до подсчёта, чтобы не терять его результат.
+func checkChartPath(path string) error {
+	if ext := strings.ToLower(filepath.Ext(path)); ext != ".svg" {
+		return fmt.Errorf(tr("неподдерживаемый формат диаграммы %q (поддерживается .svg)"), ext)
+	}
+	return nil
+}
+
+// writeBarChartSVG рисует полосы со значениями и долей от суммы.
+func writeBarChartSVG(w io.Writer, title string, bars []chartBar) error {
+	total, maxValue := 0, 0
+	for _, b := range bars {
+		total += b.value
+		maxValue = max(maxValue, b.value)
+	}
+
+	width := chartLabelWidth + chartBarWidth + chartValueWidth
+	height := chartTitleSpace + len(bars)*chartRowHeight + 10
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="13">`+"\n", width, height)
+	fmt.Fprintf(&sb, `<rect width="100%%" height="100%%" fill="#ffffff"/>`+"\n")
+	fmt.Fprintf(&sb, `<text x="10" y="24" font-size="16" font-weight="bold">%s</text>`+"\n", html.EscapeString(title))
+
+	for i, b := range bars {
+		y := chartTitleSpace + i*chartRowHeight
+		barLen := 0
+		if maxValue > 0 {
+			barLen = b.value * chartBarWidth / maxValue
+		}
+		share := 0.0
+		if total > 0 {
+			share = float64(b.value) * 100 / float64(total)
+		}
+
+		fmt.Fprintf(&sb, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n",
+			chartLabelWidth-8, y+17, html.EscapeString(b.label))
+		fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+			chartLabelWidth, y+4, max(barLen, 1), chartRowHeight-8, chartColors[i%len(chartColors)])
+		fmt.Fprintf(&sb, `<text x="%d" y="%d">%d (%.1f%%)</text>`+"\n",
+			chartLabelWidth+barLen+6, y+17, b.value, share)
+	}
+	sb.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// Размеры круговой диаграммы в пикселях.
+const (
+	pieRadius      = 110
+	pieLegendWidth = 240
+)
+
+// writePieChartSVG рисует круговую диаграмму долей с легендой справа.
+// Цвета секторов совпадают с цветами полос writeBarChartSVG.
+func writePieChartSVG(w io.Writer, bars []chartBar) error {
+	total := 0
+	for _, b := range bars {
+		total += b.value
+	}
+
+	size := 2*pieRadius + 20
+	height := max(size, 20+len(bars)*22)
+	cx, cy := float64(size)/2, float64(size)/2
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="13">`+"\n", size+pieLegendWidth, height)
+
+	angle := -math.Pi / 2 // первый сектор начинается сверху
+	for i, b := range bars {
+		if total == 0 || b.value == 0 {
+			continue
+		}
+		color := chartColors[i%len(chartColors)]
+		share := float64(b.value) / float64(total)
+		if share == 1 {
+			fmt.Fprintf(&sb, `<circle cx="%.1f" cy="%.1f" r="%d" fill="%s"/>`+"\n", cx, cy, pieRadius, color)
+			continue
+		}
+		end := angle + share*2*math.Pi
+		large := 0
+		if share > 0.5 {
+			large = 1
+		}
+		fmt.Fprintf(&sb, `<path d="M%.1f,%.1f L%.1f,%.1f A%d,%d 0 %d 1 %.1f,%.1f Z" fill="%s"/>`+"\n",
+			cx, cy, cx+pieRadius*math.Cos(angle), cy+pieRadius*math.Sin(angle),
+			pieRadius, pieRadius, large, cx+pieRadius*math.Cos(end), cy+pieRadius*math.Sin(end), color)
+		angle = end
+	}
+
+	for i, b := range bars {
+		y := 10 + i*22
+		share := 0.0
+		if total > 0 {
+			share = float64(b.value) * 100 / float64(total)
+		}
+		fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="14" height="14" fill="%s"/>`+"\n", size+10, y, chartColors[i%len(chartColors)])
+		fmt.Fprintf(&sb, `<text x="%d" y="%d">%s — %.1f%%</text>`+"\n", size+30, y+12, html.EscapeString(b.label), share)
+	}
+	sb.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
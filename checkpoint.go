@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"time"
+)
+
+// Контрольная точка сбрасывается на диск после каждых checkpointEvery файлов
+// или по прошествии checkpointInterval — смотря что наступит раньше.
+const (
+	checkpointEvery    = 1000
+	checkpointInterval = 5 * time.Second
+)
+
+// checkpoint — файл контрольной точки: заголовок с параметрами подсчёта
+// и по одной JSON-строке с путём и результатом подсчёта на каждый полностью
+// посчитанный файл. Строки только дописываются, поэтому прерванный запуск
+// оставляет корректный (пусть и неполный) файл.
+type checkpoint struct {
+	path      string
+	f         *os.File
+	w         *bufio.Writer
+	pending   int
+	lastFlush time.Time
+	err       error
+}
+
+// checkpointHeader — первая строка файла контрольной точки.
+type checkpointHeader struct {
+	Options string `json:"options"` // отпечаток countingOptions
+}
+
+// checkpointEntry — строка файла контрольной точки.
+type checkpointEntry struct {
+	Path   string     `json:"path"`
+	Counts fileCounts `json:"counts"`
+}
+
+// openCheckpoint открывает файл контрольной точки для подсчёта с параметрами
+// options (см. countingOptions). При resume уже записанные результаты
+// возвращаются в виде «путь → строки» и файл дописывается, иначе он создаётся
+// заново. Недописанная последняя строка прерванного запуска перед
+// дописыванием отрезается, чтобы новая запись не склеилась с ней. Файл,
+// записанный с другими параметрами, продолжить нельзя: его итоги не совпали бы
+// с итогами остальных файлов.
+func openCheckpoint(path string, resume bool, options string) (*checkpoint, map[string]fileCounts, error) {
+	done := make(map[string]fileCounts)
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	var complete int64
+
+	if resume {
+		var written string
+		var err error
+		if done, written, complete, err = readCheckpoint(path); err != nil {
+			return nil, nil, err
+		}
+		if complete > 0 && written != options {
+			return nil, nil, fmt.Errorf(tr("%s записан с другими параметрами подсчёта — запустите подсчёт без --resume"), path)
+		}
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resume {
+		if err := f.Truncate(complete); err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+	}
+	c := &checkpoint{path: path, f: f, w: bufio.NewWriter(f), lastFlush: time.Now()}
+	if complete == 0 {
+		line, _ := json.Marshal(checkpointHeader{options})
+		c.w.Write(append(line, '\n'))
+	}
+	return c, done, nil
+}
+
+// countingOptions возвращает отпечаток параметров, от которых зависит
+// результат подсчёта отдельного файла, — для сверки контрольной точки
+// при --resume.
+func (o *options) countingOptions() string {
+	pattern := func(re *regexp.Regexp) string {
+		if re == nil {
+			return ""
+		}
+		return re.String()
+	}
+	return fmt.Sprintf("match=%q ignore=%q imports=%t cgo=%t text=%t todos=%q style=%t duplication=%t go-detail=%t backend=%s doc-code=%t asm-syntax=%s detect=%s lang-priority=%s",
+		pattern(o.match), pattern(o.ignore), o.imports, o.cgo, o.bytes || o.tokens, pattern(o.todoPattern()),
+		o.styleStats, o.duplication, o.goDetail, o.backend, o.docCode, o.asmSyntax, o.detect, o.priority)
+}
+
+// readCheckpoint читает ранее записанный файл контрольной точки и возвращает
+// также параметры подсчёта из заголовка и длину его части до последнего
+// перевода строки. Отсутствующий файл не считается ошибкой — подсчёт просто
+// начнётся сначала. Последняя строка могла быть записана не полностью:
+// строка без перевода строки в конце и некорректный JSON пропускаются.
+func readCheckpoint(path string) (map[string]fileCounts, string, int64, error) {
+	done := make(map[string]fileCounts)
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return done, "", 0, nil
+	}
+	if err != nil {
+		return nil, "", 0, err
+	}
+	defer f.Close()
+
+	var options string
+	var complete int64
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return done, options, complete, nil
+		}
+		if err != nil {
+			return nil, "", 0, err
+		}
+		if complete == 0 {
+			var h checkpointHeader
+			json.Unmarshal(line, &h)
+			options = h.Options
+			complete += int64(len(line))
+			continue
+		}
+		complete += int64(len(line))
+		var e checkpointEntry
+		if json.Unmarshal(line, &e) == nil {
+			done[e.Path] = e.Counts
+		}
+	}
+}
+
+// add отмечает файл как посчитанный. Первая ошибка записи запоминается
+// и возвращается из close, чтобы не прерывать сам подсчёт.
+func (c *checkpoint) add(name string, counts fileCounts) {
+	if c.err != nil {
+		return
+	}
+	line, err := json.Marshal(checkpointEntry{name, counts})
+	if err == nil {
+		_, err = c.w.Write(append(line, '\n'))
+	}
+	if c.err = err; c.err != nil {
+		return
+	}
+
+	c.pending++
+	if c.pending >= checkpointEvery || time.Since(c.lastFlush) >= checkpointInterval {
+		c.flush()
+	}
+}
+
+func (c *checkpoint) flush() {
+	if c.err == nil {
+		c.err = c.w.Flush()
+	}
+	if c.err == nil {
+		c.err = c.f.Sync()
+	}
+	c.pending = 0
+	c.lastFlush = time.Now()
+}
+
+// close сбрасывает оставшиеся записи и закрывает файл.
+func (c *checkpoint) close() error {
+	c.flush()
+	if err := c.f.Close(); c.err == nil {
+		c.err = err
+	}
+	return c.err
+}
+
+// finish закрывает и удаляет файл контрольной точки после успешного
+// завершения подсчёта — продолжать больше нечего.
+func (c *checkpoint) finish() error {
+	if err := c.close(); err != nil {
+		return err
+	}
+	return os.Remove(c.path)
+}
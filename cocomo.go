@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// cocomoModel — коэффициенты базовой модели COCOMO (Boehm, 1981):
+// трудоёмкость = a·KLOC^b человеко-месяцев, срок = c·трудоёмкость^d месяцев.
+type cocomoModel struct {
+	a, b, c, d float64
+}
+
+// cocomoModels — типы проектов базовой модели COCOMO.
+var cocomoModels = map[string]cocomoModel{
+	"organic":       {2.4, 1.05, 2.5, 0.38}, // небольшая команда, знакомая задача
+	"semi-detached": {3.0, 1.12, 2.5, 0.35}, // промежуточный тип
+	"embedded":      {3.6, 1.20, 2.5, 0.32}, // жёсткие аппаратные и регламентные ограничения
+}
+
+// cocomoOptions — параметры оценки --cocomo.
+type cocomoOptions struct {
+	model    string
+	salary   float64 // годовая зарплата разработчика
+	overhead float64 // множитель накладных расходов
+}
+
+// checkCocomoModel проверяет значение --cocomo-model.
+func checkCocomoModel(model string) error {
+	if _, ok := cocomoModels[model]; !ok {
+		return fmt.Errorf(tr("неизвестная модель COCOMO %q (ожидается organic, semi-detached или embedded)"), model)
+	}
+	return nil
+}
+
+// cocomoEstimate — оценка проекта по базовой модели COCOMO.
+type cocomoEstimate struct {
+	effort   float64 // человеко-месяцы
+	schedule float64 // месяцы
+	people   float64 // средняя численность команды
+	cost     float64 // стоимость с накладными расходами
+}
+
+// estimate оценивает трудоёмкость, срок, команду и стоимость разработки
+// sloc строк кода.
+func (c cocomoOptions) estimate(sloc int) cocomoEstimate {
+	m := cocomoModels[c.model]
+	var e cocomoEstimate
+	if sloc <= 0 {
+		return e
+	}
+	e.effort = m.a * math.Pow(float64(sloc)/1000, m.b)
+	e.schedule = m.c * math.Pow(e.effort, m.d)
+	e.people = e.effort / e.schedule
+	e.cost = e.effort / 12 * c.salary * c.overhead
+	return e
+}
+
+// printCocomo выводит оценку COCOMO для строк кода отчёта — как раздел
+// оценки в scc. Оценка грубая: модель учитывает только объём кода.
+func printCocomo(rep *report, c cocomoOptions) {
+	e := c.estimate(rep.totalLines)
+	fmt.Printf(tr("Оценка COCOMO (базовая модель, %s):\n"), c.model)
+	fmt.Printf(tr("  Трудоёмкость:  %.1f человеко-месяцев\n"), e.effort)
+	fmt.Printf(tr("  Срок:          %.1f месяцев\n"), e.schedule)
+	fmt.Printf(tr("  Команда:       %.1f человек\n"), e.people)
+	fmt.Printf(tr("  Стоимость:     %s (зарплата %s в год, накладные ×%s)\n"),
+		groupThousands(e.cost), groupThousands(c.salary), strconv.FormatFloat(c.overhead, 'g', -1, 64))
+	fmt.Println()
+}
+
+// groupThousands округляет v до целого и разделяет разряды пробелами.
+func groupThousands(v float64) string {
+	s := strconv.FormatFloat(math.Round(v), 'f', 0, 64)
+	var sb strings.Builder
+	for i, r := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// Escape-последовательности ANSI для оформления таблиц.
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiRed   = "\x1b[31m"
+)
+
+// languagePalette — цвета языков. Красный не используется: им отмечаются
+// файлы сверх --max-lines.
+var languagePalette = []string{
+	"\x1b[32m", "\x1b[33m", "\x1b[34m", "\x1b[35m", "\x1b[36m",
+	"\x1b[92m", "\x1b[93m", "\x1b[94m", "\x1b[95m", "\x1b[96m",
+}
+
+// colorOutput включает цвета в таблицах отчёта. Устанавливается только
+// на время вывода в терминал, поэтому отчёты в файлах остаются без цветов.
+var colorOutput bool
+
+// checkColorMode проверяет значение --color.
+func checkColorMode(mode string) error {
+	switch mode {
+	case "auto", "always", "never":
+		return nil
+	}
+	return fmt.Errorf(tr("неизвестное значение --color %q (ожидается auto, always или never)"), mode)
+}
+
+// colorEnabled сообщает, нужны ли цвета при выводе в stdout. В режиме
+// auto цвета включаются только для терминала и, по соглашению no-color.org,
+// отключаются переменной NO_COLOR.
+func (o *options) colorEnabled() bool {
+	switch o.color {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isTerminal(os.Stdout)
+}
+
+// paint оборачивает s в escape-последовательность code, если цвета включены.
+func paint(code, s string) string {
+	if !colorOutput || s == "" {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+// languageColor возвращает цвет языка. Цвет выбирается по хешу имени,
+// поэтому у языка он один и тот же во всех отчётах.
+func languageColor(lang string) string {
+	h := fnv.New32a()
+	h.Write([]byte(lang))
+	return languagePalette[h.Sum32()%uint32(len(languagePalette))]
+}
+
+// paintRow возвращает копию строки таблицы, все ячейки которой
+// обёрнуты в code.
+func paintRow(code string, row []string) []string {
+	painted := make([]string, len(row))
+	for i, cell := range row {
+		painted[i] = paint(code, cell)
+	}
+	return painted
+}
+
+// visibleLen возвращает ширину s в символах без учёта escape-последовательностей.
+func visibleLen(s string) int {
+	n := 0
+	for s != "" {
+		if strings.HasPrefix(s, "\x1b[") {
+			end := strings.IndexByte(s, 'm')
+			if end < 0 {
+				break
+			}
+			s = s[end+1:]
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s)
+		s = s[size:]
+		n++
+	}
+	return n
+}
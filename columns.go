@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// fileColumn — столбец таблицы файлов, выбираемый через --columns.
+type fileColumn struct {
+	header  string
+	numeric bool
+	value   func(f fileResult) string
+	// total возвращает значение в строке итогов; nil — ячейка пустая
+	total func(rep *report) string
+}
+
+// fileColumnNames — имена столбцов --columns в порядке справки.
+var fileColumnNames = []string{
+	"path", "lang", "ext", "code", "comments", "blanks", "total",
+	"density", "bytes", "chars", "tokens", "todos",
+	"longest", "indent", "trailing", "duplication", "imports", "size", "modified", "author", "commit",
+}
+
+// sumFiles возвращает итог столбца по всем файлам отчёта.
+func sumFiles(value func(f fileResult) int) func(rep *report) string {
+	return func(rep *report) string {
+		n := 0
+		for _, f := range rep.files {
+			n += value(f)
+		}
+		return strconv.Itoa(n)
+	}
+}
+
+var fileColumns = map[string]fileColumn{
+	"path": {"Файл", false, func(f fileResult) string { return f.path },
+		func(rep *report) string { return fmt.Sprintf(tr("Итого (%d файлов)"), len(rep.files)) }},
+	"lang": {"Язык", false, func(f fileResult) string { return f.lang }, nil},
+	"ext":  {"Расширение", false, func(f fileResult) string { return fileExt(f.path) }, nil},
+	"code": {"Строки", true, func(f fileResult) string { return strconv.Itoa(f.lines) },
+		sumFiles(func(f fileResult) int { return f.lines })},
+	"comments": {"Комментарии", true, func(f fileResult) string { return strconv.Itoa(f.comments) },
+		sumFiles(func(f fileResult) int { return f.comments })},
+	"blanks": {"Пустые", true, func(f fileResult) string { return strconv.Itoa(f.blanks) },
+		sumFiles(func(f fileResult) int { return f.blanks })},
+	"total": {"Всего", true, func(f fileResult) string { return strconv.Itoa(f.lines + f.comments + f.blanks) },
+		sumFiles(func(f fileResult) int { return f.lines + f.comments + f.blanks })},
+	"density": {"Плотность", true, func(f fileResult) string { return formatShare(f.comments, f.lines+f.comments) },
+		func(rep *report) string { return formatShare(rep.totalComments, rep.totalLines+rep.totalComments) }},
+	"bytes": {"Байт", true, func(f fileResult) string { return strconv.Itoa(f.bytes) },
+		sumFiles(func(f fileResult) int { return f.bytes })},
+	"chars": {"Символы", true, func(f fileResult) string { return strconv.Itoa(f.chars) },
+		sumFiles(func(f fileResult) int { return f.chars })},
+	"tokens": {"Токены", true, func(f fileResult) string { return strconv.Itoa(f.tokens) },
+		sumFiles(func(f fileResult) int { return f.tokens })},
+	"todos": {"Метки", true, func(f fileResult) string { return strconv.Itoa(len(f.todos)) },
+		sumFiles(func(f fileResult) int { return len(f.todos) })},
+	"longest": {"Макс. длина", true, func(f fileResult) string { return strconv.Itoa(f.longest) },
+		func(rep *report) string {
+			n := 0
+			for _, f := range rep.files {
+				n = max(n, f.longest)
+			}
+			return strconv.Itoa(n)
+		}},
+	"indent": {"Отступы", false, func(f fileResult) string { return tr(indentLabels[f.indent]) }, nil},
+	"trailing": {"Пробелы в конце", true, func(f fileResult) string { return strconv.Itoa(f.trailing) },
+		sumFiles(func(f fileResult) int { return f.trailing })},
+	"duplication": {"Повторы", true, func(f fileResult) string { return formatShare(f.duplicated, f.hashedLines) },
+		func(rep *report) string { return formatShare(rep.duplicationTotals()) }},
+	"imports": {"Импорты", true, func(f fileResult) string { return strconv.Itoa(f.imports) },
+		sumFiles(func(f fileResult) int { return f.imports })},
+	"size": {"Байт", true, func(f fileResult) string { return strconv.FormatInt(f.size, 10) },
+		func(rep *report) string {
+			var n int64
+			for _, f := range rep.files {
+				n += f.size
+			}
+			return strconv.FormatInt(n, 10)
+		}},
+	"modified": {"Изменён", false, func(f fileResult) string {
+		if f.modTime.IsZero() {
+			return "-"
+		}
+		return f.modTime.Format("2006-01-02 15:04")
+	}, nil},
+	"author": {"Автор", false, func(f fileResult) string {
+		if f.commit == nil {
+			return "-"
+		}
+		return f.commit.author
+	}, nil},
+	"commit": {"Коммит", false, func(f fileResult) string {
+		if f.commit == nil {
+			return "-"
+		}
+		return f.commit.date
+	}, nil},
+}
+
+// columnList — значение флага --columns: имена столбцов через запятую.
+type columnList []string
+
+func (c *columnList) String() string { return strings.Join(*c, ",") }
+func (c *columnList) Set(v string) error {
+	for _, name := range strings.Split(v, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := fileColumns[name]; !ok {
+			return fmt.Errorf(tr("неизвестный столбец %q (доступны: %s)"), name, strings.Join(fileColumnNames, ", "))
+		}
+		*c = append(*c, name)
+	}
+	return nil
+}
+
+// defaultColumns возвращает столбцы таблицы файлов без --columns:
+// путь и строки, а также разбивку строк, импорты и метаданные, если
+// они запрошены.
+func (o *options) defaultColumns() []string {
+	columns := []string{"path", "code"}
+	if o.breakdown {
+		columns = append(columns, "comments", "blanks", "total")
+	}
+	if o.density {
+		columns = append(columns, "density")
+	}
+	if o.bytes {
+		columns = append(columns, "bytes", "chars")
+	}
+	if o.tokens {
+		columns = append(columns, "tokens")
+	}
+	if o.todos {
+		columns = append(columns, "todos")
+	}
+	if o.styleStats {
+		columns = append(columns, "longest", "indent", "trailing")
+	}
+	if o.duplication {
+		columns = append(columns, "duplication")
+	}
+	if o.imports {
+		columns = append(columns, "imports")
+	}
+	if o.meta {
+		columns = append(columns, "size", "modified", "author", "commit")
+	}
+	return columns
+}
+
+// printFileColumns выводит таблицу файлов со столбцами --columns в стиле
+// --style. Строки файлов сверх --max-lines выделяются красным.
+func printFileColumns(rep *report, opts *options) {
+	columns := []string(opts.columns)
+	if len(columns) == 0 {
+		columns = opts.defaultColumns()
+	}
+
+	headers := make([]string, len(columns))
+	numeric := make([]bool, len(columns))
+	totals := make([]string, len(columns))
+	for i, name := range columns {
+		col := fileColumns[name]
+		headers[i], numeric[i] = tr(col.header), col.numeric
+		if col.total != nil {
+			totals[i] = col.total(rep)
+		}
+	}
+	if totals[0] == "" {
+		totals[0] = tr("Итого")
+	}
+
+	rows := make([][]string, 0, len(rep.files))
+	for _, f := range opts.shownFiles(rep) {
+		row := make([]string, len(columns))
+		for i, name := range columns {
+			row[i] = fileColumns[name].value(f)
+		}
+		if opts.maxLines > 0 && f.lines > opts.maxLines {
+			row = paintRow(ansiRed, row)
+		} else {
+			row[0] = paint(languageColor(f.lang), row[0])
+		}
+		rows = append(rows, row)
+	}
+
+	fmt.Println()
+	renderTable(headers, rows, totals, numeric)
+}
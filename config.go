@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// configLanguages — файлы конфигурации, которые учитываются с
+// --include-config. Их строки не входят в итоги кода, а выводятся
+// отдельной группой: так видно, сколько в репозитории конфигурации.
+var configLanguages = map[string]LangConfig{
+	".yaml": yamlConfig,
+	".yml":  yamlConfig,
+	".toml": {
+		Name:       "TOML",
+		SingleLine: []string{"#"},
+		Strings:    []string{`"`, `'`},
+		MultilineStrings: []multilineString{
+			{Start: `"""`, End: `"""`, Escape: true},
+			{Start: "'''", End: "'''"},
+		},
+	},
+	".ini":  {Name: "INI", SingleLine: []string{";", "#"}},
+	".json": {Name: "JSON", Strings: []string{`"`}},
+}
+
+var yamlConfig = LangConfig{Name: "YAML", SingleLine: []string{"#"}, Strings: []string{`"`, `'`}}
+
+// isConfigLanguage сообщает, относится ли язык lang к файлам конфигурации.
+func isConfigLanguage(lang string) bool {
+	for _, cfg := range configLanguages {
+		if cfg.Name == lang {
+			return true
+		}
+	}
+	return false
+}
+
+// addConfig учитывает файл конфигурации в отдельной группе.
+func (r *report) addConfig(res fileResult) {
+	r.addSubtotal(r.config, res.lang, res.lines)
+	r.config[res.lang].comments += res.comments
+	r.config[res.lang].blanks += res.blanks
+}
+
+// configTotal возвращает общий итог по файлам конфигурации.
+func (r *report) configTotal() subtotal {
+	var total subtotal
+	for _, t := range r.config {
+		total.files += t.files
+		total.lines += t.lines
+		total.comments += t.comments
+		total.blanks += t.blanks
+	}
+	return total
+}
+
+// configLanguageNames возвращает языки конфигурации отчёта
+// по убыванию числа строк.
+func (r *report) configLanguageNames() []string {
+	names := make([]string, 0, len(r.config))
+	for name := range r.config {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if r.config[names[i]].lines != r.config[names[j]].lines {
+			return r.config[names[i]].lines > r.config[names[j]].lines
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// printConfig выводит группу файлов конфигурации (--include-config)
+// и её размер относительно кода.
+func printConfig(rep *report) {
+	total := rep.configTotal()
+	fmt.Printf(tr("Конфигурация (не учтена в итогах кода): файлов %d, строк %d\n"), total.files, total.lines)
+	if total.files == 0 {
+		fmt.Println()
+		return
+	}
+	rows := make([][]string, 0, len(rep.config))
+	for _, name := range rep.configLanguageNames() {
+		t := rep.config[name]
+		rows = append(rows, []string{name, strconv.Itoa(t.files), strconv.Itoa(t.lines), strconv.Itoa(t.comments), strconv.Itoa(t.blanks)})
+	}
+	renderTable([]string{tr("Язык"), tr("Файлы"), tr("Строки"), tr("Комментарии"), tr("Пустые")}, rows, nil, []bool{false, true, true, true, true})
+	if rep.totalLines > 0 {
+		fmt.Printf(tr("Строк конфигурации на 100 строк кода: %.1f\n"), float64(total.lines)*100/float64(rep.totalLines))
+	}
+	fmt.Println()
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// coverageProfile — покрытие строк по файлам: путь из профиля →
+// номер строки → покрыта ли она хотя бы одним тестом.
+type coverageProfile map[string]map[int]bool
+
+// loadCoverage читает профиль покрытия Go (go test -coverprofile)
+// или файл lcov. Формат определяется по первой строке.
+func loadCoverage(name string) (coverageProfile, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cov := make(coverageProfile)
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		return cov, scanner.Err()
+	}
+	if strings.HasPrefix(scanner.Text(), "mode:") {
+		err = cov.parseGo(scanner)
+	} else {
+		err = cov.parseLcov(scanner.Text(), scanner)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return cov, nil
+}
+
+// mark отмечает строку файла: покрытая строка остаётся покрытой,
+// даже если её задевает и непокрытый блок.
+func (c coverageProfile) mark(file string, line int, covered bool) {
+	lines := c[file]
+	if lines == nil {
+		lines = make(map[int]bool)
+		c[file] = lines
+	}
+	lines[line] = lines[line] || covered
+}
+
+// parseGo разбирает строки профиля Go вида
+// "файл:начСтрока.начСтолбец,конСтрока.конСтолбец операторов счётчик".
+func (c coverageProfile) parseGo(scanner *bufio.Scanner) error {
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		file, block, ok := strings.Cut(line, ":")
+		fields := strings.Fields(block)
+		if !ok || len(fields) != 3 {
+			return fmt.Errorf(tr("некорректная строка профиля %q"), line)
+		}
+		start, end, _ := strings.Cut(fields[0], ",")
+		startLine, err1 := strconv.Atoi(strings.Split(start, ".")[0])
+		endLine, err2 := strconv.Atoi(strings.Split(end, ".")[0])
+		count, err3 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil || err3 != nil {
+			return fmt.Errorf(tr("некорректная строка профиля %q"), line)
+		}
+		for l := startLine; l <= endLine; l++ {
+			c.mark(file, l, count > 0)
+		}
+	}
+	return scanner.Err()
+}
+
+// parseLcov разбирает записи lcov: SF:файл, DA:строка,попаданий, end_of_record.
+func (c coverageProfile) parseLcov(first string, scanner *bufio.Scanner) error {
+	var file string
+	line := first
+	for {
+		switch {
+		case strings.HasPrefix(line, "SF:"):
+			file = strings.TrimPrefix(line, "SF:")
+		case strings.HasPrefix(line, "DA:") && file != "":
+			fields := strings.Split(strings.TrimPrefix(line, "DA:"), ",")
+			if len(fields) < 2 {
+				return fmt.Errorf(tr("некорректная строка lcov %q"), line)
+			}
+			n, err1 := strconv.Atoi(fields[0])
+			hits, err2 := strconv.Atoi(fields[1])
+			if err1 != nil || err2 != nil {
+				return fmt.Errorf(tr("некорректная строка lcov %q"), line)
+			}
+			c.mark(file, n, hits > 0)
+		case line == "end_of_record":
+			file = ""
+		}
+		if !scanner.Scan() {
+			return scanner.Err()
+		}
+		line = scanner.Text()
+	}
+}
+
+// lookup находит покрытие файла отчёта: абсолютные пути профиля
+// сравниваются с абсолютным путём файла, относительные и пути импорта Go
+// (github.com/user/repo/pkg/file.go) — по совпадению окончания пути.
+func (c coverageProfile) lookup(file, rel string) (map[int]bool, bool) {
+	abs, _ := filepath.Abs(file)
+	abs = filepath.ToSlash(abs)
+	for key, lines := range c {
+		if path.IsAbs(key) || filepath.IsAbs(key) {
+			if filepath.ToSlash(key) == abs {
+				return lines, true
+			}
+			continue
+		}
+		key = strings.TrimPrefix(key, "./")
+		if strings.HasSuffix("/"+key, "/"+rel) || strings.HasSuffix("/"+rel, "/"+key) {
+			return lines, true
+		}
+	}
+	return nil, false
+}
+
+// coverageRow — покрытие кода одного файла или пакета.
+type coverageRow struct {
+	code, covered int
+}
+
+func (r coverageRow) cells(name string) []string {
+	percent := "-"
+	if r.code > 0 {
+		percent = strconv.FormatFloat(float64(r.covered)*100/float64(r.code), 'f', 1, 64) + "%"
+	}
+	return []string{name, strconv.Itoa(r.code), strconv.Itoa(r.covered), percent}
+}
+
+// printCoverage выводит покрытые и все строки кода по файлам и пакетам
+// (директориям). Учитываются файлы тех языков, что есть в профиле: файл
+// такого языка, отсутствующий в профиле, считается полностью непокрытым.
+func printCoverage(rep *report, cov coverageProfile) {
+	if rep.root == "" {
+		fmt.Println(tr("Покрытие: доступно только при подсчёте директории."))
+		fmt.Println()
+		return
+	}
+
+	type fileCoverage struct {
+		f     fileResult
+		lines map[int]bool
+	}
+	var matched []fileCoverage
+	langs := make(map[string]bool)
+	for _, f := range rep.files {
+		if f.src == "" {
+			continue
+		}
+		rel, err := filepath.Rel(rep.root, f.src)
+		if err != nil {
+			rel = f.src
+		}
+		lines, ok := cov.lookup(f.src, filepath.ToSlash(rel))
+		if ok {
+			langs[f.lang] = true
+		}
+		matched = append(matched, fileCoverage{f, lines})
+	}
+
+	var rows [][]string
+	var total coverageRow
+	packages := make(map[string]*coverageRow)
+	for _, m := range matched {
+		if !langs[m.f.lang] {
+			continue
+		}
+		cfg, ok := languageConfig(m.f.lang)
+		if !ok {
+			continue
+		}
+		row, err := coveredLines(m.f.src, cfg, m.lines)
+		if err != nil {
+			rep.skipped = append(rep.skipped, skippedFile{m.f.path, err.Error()})
+			continue
+		}
+		rows = append(rows, row.cells(m.f.path))
+		total.code += row.code
+		total.covered += row.covered
+
+		pkg := path.Dir(m.f.path)
+		if packages[pkg] == nil {
+			packages[pkg] = &coverageRow{}
+		}
+		packages[pkg].code += row.code
+		packages[pkg].covered += row.covered
+	}
+	if len(rows) == 0 {
+		fmt.Println(tr("Покрытие: ни один файл отчёта не найден в профиле."))
+		fmt.Println()
+		return
+	}
+
+	headers := []string{tr("Файл"), tr("Строки"), tr("Покрыто"), tr("Покрытие")}
+	printTable(headers, rows, total.cells(tr("Итого")))
+
+	names := make([]string, 0, len(packages))
+	for pkg := range packages {
+		names = append(names, pkg)
+	}
+	sort.Strings(names)
+	pkgRows := make([][]string, 0, len(names))
+	for _, pkg := range names {
+		pkgRows = append(pkgRows, packages[pkg].cells(pkg))
+	}
+	headers[0] = tr("Пакет")
+	printTable(headers, pkgRows, nil)
+}
+
+// coveredLines перечитывает файл name (путь на диске) и считает его строки кода и те из них,
+// что покрыты по профилю lines.
+func coveredLines(name string, cfg LangConfig, lines map[int]bool) (coverageRow, error) {
+	f, err := os.Open(longPath(name))
+	if err != nil {
+		return coverageRow{}, err
+	}
+	defer f.Close()
+
+	var row coverageRow
+	classifier := lineClassifier{cfg: cfg}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		if classifier.classify(scanner.Text()) != lineCode {
+			continue
+		}
+		row.code++
+		if lines[n] {
+			row.covered++
+		}
+	}
+	return row, scanner.Err()
+}
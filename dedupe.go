@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// contentDuplicate — файл, побайтно совпадающий с ранее найденным (--dedupe).
+type contentDuplicate struct {
+	path     string
+	original string // первый файл с тем же содержимым
+	lines    int
+}
+
+// contentIndex запоминает хеши содержимого посчитанных файлов, чтобы
+// находить побайтные копии — например, скопированные vendored-файлы.
+type contentIndex map[string]string // SHA-256 -> первый файл
+
+// duplicateOf возвращает файл, с которым res совпадает по содержимому,
+// или запоминает res как первый файл с таким содержимым. Пустые файлы
+// не сравниваются: совпадение у них ничего не значит.
+func (idx contentIndex) duplicateOf(res fileResult) (string, bool) {
+	if res.size == 0 {
+		return "", false
+	}
+	if original, ok := idx[res.sha256]; ok {
+		return original, true
+	}
+	idx[res.sha256] = res.path
+	return "", false
+}
+
+// keep проверяет res на совпадение с уже посчитанными файлами при --dedupe:
+// копию записывает в rep и возвращает false, если при --dedupe-exclude
+// её не нужно учитывать в итогах.
+func (idx contentIndex) keep(res fileResult, rep *report, opts *options) bool {
+	if !opts.dedupe {
+		return true
+	}
+	if original, dup := idx.duplicateOf(res); dup {
+		rep.addDuplicate(res, original)
+		return !opts.dedupeExclude
+	}
+	return true
+}
+
+// addDuplicate учитывает копию res файла original.
+func (r *report) addDuplicate(res fileResult, original string) {
+	r.contentDuplicates = append(r.contentDuplicates, contentDuplicate{res.path, original, res.lines})
+}
+
+// printContentDuplicates выводит копии файлов и оригиналы, с которыми
+// они совпадают.
+func printContentDuplicates(rep *report, opts *options) {
+	lines := 0
+	rows := make([][]string, 0, len(rep.contentDuplicates))
+	for _, d := range rep.contentDuplicates {
+		lines += d.lines
+		rows = append(rows, []string{d.path, d.original, strconv.Itoa(d.lines)})
+	}
+	if opts.dedupeExclude {
+		fmt.Printf(tr("Копии файлов по содержимому: %d, строк: %d (не учтены в итогах)\n"), len(rows), lines)
+	} else {
+		fmt.Printf(tr("Копии файлов по содержимому: %d, строк: %d\n"), len(rows), lines)
+	}
+	if len(rows) == 0 {
+		fmt.Println()
+		return
+	}
+	renderTable([]string{tr("Файл"), tr("Совпадает с"), tr("Строки")}, rows, nil, []bool{false, false, true})
+	fmt.Println()
+}
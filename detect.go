@@ -0,0 +1,360 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ambiguousExtensions — расширения, которыми пользуются несколько языков:
+// кандидаты в порядке приоритета. Первый кандидат совпадает с записью
+// в knownLanguages и выбирается, если содержимое файла не указывает
+// на другой язык. Порядок можно переопределить флагом --lang-priority
+// и файлом languages (см. languagesPath).
+var ambiguousExtensions = map[string][]string{
+	// Признаки Objective-C однозначнее признаков C++ (в заголовке
+	// Objective-C++ встречаются и те, и другие), поэтому проверяются раньше
+	".h": {"C", "Objective-C", "C++"},
+}
+
+// languageHints — признаки языка в начале файла, по которым выбирается
+// один из кандидатов неоднозначного расширения. Язык без признаков
+// выбирается только по приоритету.
+var languageHints = map[string]*regexp.Regexp{
+	"Objective-C": regexp.MustCompile(`(?m)^\s*(@interface|@protocol|@implementation|@class|#import)\b`),
+	"C++":         regexp.MustCompile(`(?m)^\s*(class\s+\w+|namespace\s+\w+|template\s*<|#include\s*<(iostream|string|vector|map|memory)>)|std::`),
+}
+
+// plainTextConfig — файл нераспознанного типа при --count-unknown:
+// без правил комментариев учитываются все непустые строки.
+var plainTextConfig = LangConfig{Name: "Other"}
+
+// headSize — сколько байт от начала файла просматривается при выборе языка.
+const headSize = 8 << 10
+
+// preferredExtensions — расширение, чья конфигурация выбирается для языка
+// с несколькими конфигурациями, когда язык известен только по названию
+// (--lang-priority, modeline, тег блока кода).
+var preferredExtensions = map[string]string{
+	"Fortran":  ".f90", // свободная форма, а не фиксированная (.f, .for)
+	"Assembly": ".s",
+}
+
+// languageConfig возвращает конфигурацию языка по названию
+// без учёта регистра. Из нескольких конфигураций языка берётся указанная
+// в preferredExtensions, иначе — конфигурация наименьшего по алфавиту
+// расширения, чтобы результат не зависел от порядка обхода карты.
+func languageConfig(name string) (LangConfig, bool) {
+	found := ""
+	for ext, cfg := range knownLanguages {
+		if strings.EqualFold(cfg.Name, name) && (found == "" || ext < found) {
+			found = ext
+		}
+	}
+	if found == "" {
+		return LangConfig{}, false
+	}
+	if ext, ok := preferredExtensions[knownLanguages[found].Name]; ok {
+		found = ext
+	}
+	return knownLanguages[found], true
+}
+
+// languagePriority задаёт порядок языков-кандидатов для расширений.
+// Как флаг принимает «.расширение=Язык,Язык», например --lang-priority .h=C++,C.
+type languagePriority map[string][]string
+
+func (p languagePriority) String() string {
+	exts := make([]string, 0, len(p))
+	for ext := range p {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	parts := make([]string, 0, len(exts))
+	for _, ext := range exts {
+		parts = append(parts, ext+"="+strings.Join(p[ext], ","))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (p languagePriority) Set(v string) error {
+	ext, langs, ok := strings.Cut(v, "=")
+	if !ok || strings.TrimSpace(langs) == "" {
+		return fmt.Errorf(tr("ожидается «.расширение=Язык,Язык», получено %q"), v)
+	}
+	var names []string
+	for _, lang := range strings.Split(langs, ",") {
+		cfg, ok := languageConfig(strings.TrimSpace(lang))
+		if !ok {
+			return fmt.Errorf(tr("неизвестный язык %q"), strings.TrimSpace(lang))
+		}
+		names = append(names, cfg.Name)
+	}
+	p[normalizeExt(strings.TrimSpace(ext))] = names
+	return nil
+}
+
+// languagesPath возвращает путь к файлу порядка языков:
+// $XDG_CONFIG_HOME/loc_counter/languages (или аналог на macOS и Windows).
+func languagesPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "loc_counter", "languages"), nil
+}
+
+// load дополняет p порядком языков из файла languagesPath: по строке
+// «.расширение = Язык,Язык»; пустые строки и строки, начинающиеся с #,
+// пропускаются. Расширения, уже заданные флагом --lang-priority, файл
+// не переопределяет. Отсутствие файла — не ошибка.
+func (p languagePriority) load() error {
+	name, err := languagesPath()
+	if err != nil {
+		return nil
+	}
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fromFile := make(languagePriority)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := fromFile.Set(line); err != nil {
+			return fmt.Errorf("%s:%d: %v", name, n, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	for ext, langs := range fromFile {
+		if _, ok := p[ext]; !ok {
+			p[ext] = langs
+		}
+	}
+	return nil
+}
+
+// language определяет язык файла с именем name (без директорий): сначала
+// по knownFilenames, затем по расширению. Для неоднозначных
+// расширений выбирается первый кандидат, чьи признаки нашлись в начале
+// файла (head возвращает его лениво; nil — содержимое недоступно),
+// а если таких нет — первый по приоритету. Файл без расширения
+// распознаётся по строке #!. При --count-unknown текстовый
+// файл нераспознанного типа считается простым текстом (Other).
+func (o *options) language(name string, head func() []byte) (LangConfig, bool) {
+	ext := strings.ToLower(path.Ext(name))
+	if o.docCode {
+		if cfg, ok := docLanguages[ext]; ok {
+			return cfg, true
+		}
+	}
+	if o.includeConfig {
+		if cfg, ok := configLanguages[ext]; ok {
+			return cfg, true
+		}
+	}
+	if _, ok := asmDefaults[ext]; ok {
+		return o.asmLanguage(ext, head), true
+	}
+
+	candidates, ok := o.priority[ext]
+	if !ok {
+		candidates = ambiguousExtensions[ext]
+	}
+	content := o.detect == "content" && head != nil
+	if len(candidates) == 0 {
+		cfg, ok := languageByName(name)
+		if !ok && ext == "" && head != nil {
+			cfg, ok = shebangLanguage(head())
+		}
+		if !ok && content && isText(head()) {
+			if cfg, ok = modelineLanguage(head()); !ok && ext == "" {
+				cfg, ok = keywordLanguage(head())
+			}
+		}
+		if !ok && o.countUnknown && head != nil && isText(head()) {
+			return plainTextConfig, true
+		}
+		return cfg, ok
+	}
+
+	if content {
+		if cfg, ok := modelineLanguage(head()); ok {
+			return cfg, true
+		}
+	}
+	if len(candidates) > 1 && head != nil {
+		content := head()
+		for _, name := range candidates {
+			if hint := languageHints[name]; hint != nil && hint.Match(content) {
+				return languageConfig(name)
+			}
+		}
+	}
+	return languageConfig(candidates[0])
+}
+
+// interpreterLanguages сопоставляет интерпретатор из строки #! и язык.
+// Номер версии в имени интерпретатора (python3, perl5.36) не учитывается.
+var interpreterLanguages = map[string]string{
+	"sh":         "Shell",
+	"bash":       "Shell",
+	"zsh":        "Shell",
+	"dash":       "Shell",
+	"ksh":        "Shell",
+	"python":     "Python",
+	"pypy":       "Python",
+	"ruby":       "Ruby",
+	"perl":       "Perl",
+	"php":        "PHP",
+	"node":       "JavaScript",
+	"ts-node":    "TypeScript",
+	"runghc":     "Haskell",
+	"runhaskell": "Haskell",
+	"swift":      "Swift",
+	"scala":      "Scala",
+}
+
+// shebangLanguage определяет язык файла без расширения по строке #!:
+// «#!/bin/bash», «#!/usr/bin/python3», «#!/usr/bin/env -S node --flag».
+func shebangLanguage(head []byte) (LangConfig, bool) {
+	line, _, _ := bytes.Cut(head, []byte("\n"))
+	rest, ok := bytes.CutPrefix(line, []byte("#!"))
+	if !ok {
+		return LangConfig{}, false
+	}
+	fields := strings.Fields(string(rest))
+	if len(fields) > 0 && path.Base(fields[0]) == "env" {
+		// Пропускаем ключи env и присваивания переменных окружения
+		fields = fields[1:]
+		for len(fields) > 0 && (strings.HasPrefix(fields[0], "-") || strings.Contains(fields[0], "=")) {
+			fields = fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return LangConfig{}, false
+	}
+	name, ok := interpreterLanguages[strings.TrimRight(path.Base(fields[0]), "0123456789.")]
+	if !ok {
+		return LangConfig{}, false
+	}
+	return languageConfig(name)
+}
+
+// checkDetect проверяет значение --detect.
+func checkDetect(detect string) error {
+	switch detect {
+	case "extension", "content":
+		return nil
+	}
+	return fmt.Errorf(tr("неизвестный способ определения языка --detect %q (ожидается extension или content)"), detect)
+}
+
+var (
+	// emacsModeline — «-*- mode: python -*-» или короткая форма «-*- c++ -*-»
+	emacsModeline = regexp.MustCompile(`-\*-\s*(?:.*?\bmode:\s*)?([\w+#-]+)[\s;]*(?:.*?)-\*-`)
+	// vimModeline — «vim: set ft=ruby:», «vi: filetype=sh», «ex: syntax=perl»
+	vimModeline = regexp.MustCompile(`(?:^|\s)(?:vim?|ex):.*?\b(?:ft|filetype|syntax)=([\w+#-]+)`)
+)
+
+// modelineAliases — названия режимов Emacs и типов файлов Vim, которые
+// не находятся через languageByTag.
+var modelineAliases = map[string]string{
+	"shell-script": "Shell",
+	"sh":           "Shell",
+	"make":         "Make",
+	"makefile":     "Make",
+	"cperl":        "Perl",
+	"js":           "JavaScript",
+	"js2":          "JavaScript",
+}
+
+// modelineLanguage определяет язык по modeline Emacs или Vim в первых
+// строках файла (--detect content).
+func modelineLanguage(head []byte) (LangConfig, bool) {
+	lines := bytes.SplitN(head, []byte("\n"), 6)
+	for _, line := range lines[:min(len(lines), 5)] {
+		m := emacsModeline.FindSubmatch(line)
+		if m == nil {
+			m = vimModeline.FindSubmatch(line)
+		}
+		if m == nil {
+			continue
+		}
+		mode := strings.ToLower(string(m[1]))
+		if name, ok := modelineAliases[mode]; ok {
+			return languageConfig(name)
+		}
+		if cfg, ok := languageByTag(mode); ok {
+			return cfg, true
+		}
+	}
+	return LangConfig{}, false
+}
+
+// keywordHints — характерные для языка строки. Язык файла без расширения
+// выбирается по числу совпавших строк (--detect content).
+var keywordHints = map[string]*regexp.Regexp{
+	"Python":     regexp.MustCompile(`(?m)^\s*(def \w+\(.*\)\s*(->.*)?:|class \w+(\(.*\))?:|from [\w.]+ import |import \w+$|if __name__ == )`),
+	"Shell":      regexp.MustCompile(`(?m)^\s*(if \[|fi$|then$|done$|esac$|set -[euxo]|export \w+=|\w+\(\) \{)`),
+	"Ruby":       regexp.MustCompile(`(?m)^\s*(require ['"]|require_relative |module [A-Z]\w*$|def \w+[!?]?(\(.*\))?$|end$|puts )`),
+	"Perl":       regexp.MustCompile(`(?m)^\s*(use strict;|use warnings;|my [$@%]\w+|sub \w+ \{)`),
+	"PHP":        regexp.MustCompile(`(?m)^\s*(<\?php|namespace [\w\\]+;|\$\w+ = )`),
+	"JavaScript": regexp.MustCompile(`(?m)^\s*((const|let|var) \w+ = require\(|module\.exports|console\.log\(|function \w+\()`),
+	"Go":         regexp.MustCompile(`(?m)^(package \w+$|func (\(.*\) )?\w+\()`),
+	"C":          regexp.MustCompile(`(?m)^\s*(#include\s*[<"]|int main\()`),
+}
+
+// keywordLanguage определяет язык текста по ключевым словам: выбирается
+// язык, у которого совпало больше всего строк, но не меньше двух.
+// При равенстве язык не определяется.
+func keywordLanguage(head []byte) (LangConfig, bool) {
+	best, bestScore, tie := "", 1, false
+	for name, re := range keywordHints {
+		switch score := len(re.FindAllIndex(head, -1)); {
+		case score > bestScore:
+			best, bestScore, tie = name, score, false
+		case score == bestScore:
+			tie = true
+		}
+	}
+	if best == "" || tie {
+		return LangConfig{}, false
+	}
+	return languageConfig(best)
+}
+
+// fileHead возвращает начало файла для определения языка по содержимому.
+func fileHead(path string) []byte {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	buf := make([]byte, headSize)
+	n, _ := io.ReadFull(f, buf)
+	return buf[:n]
+}
+
+// isText сообщает, похоже ли начало файла на текст: двоичные файлы почти
+// всегда содержат нулевые байты в первых килобайтах.
+func isText(head []byte) bool {
+	return !bytes.Contains(head, []byte{0})
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// embedFinder распознаёт встроенные блоки кода в документе, получая его
+// строки по порядку. Для каждой строки возвращает метку языка блока,
+// которому она принадлежит ("" — текст документа или разметка блока).
+type embedFinder interface {
+	lang(line string) string
+}
+
+// docLanguages — документы, код в которых учитывается с --doc-code.
+var docLanguages = map[string]LangConfig{
+	".md":       {Name: "Markdown", Embedded: func() embedFinder { return &markdownFinder{} }},
+	".markdown": {Name: "Markdown", Embedded: func() embedFinder { return &markdownFinder{} }},
+	".rst":      {Name: "reStructuredText", Embedded: func() embedFinder { return &rstFinder{} }},
+	".org":      {Name: "Org", Embedded: func() embedFinder { return &orgFinder{} }},
+}
+
+// languageAliases — метки языков в документах, которые не совпадают
+// ни с названием языка, ни с расширением.
+var languageAliases = map[string]string{
+	"python3":    "Python",
+	"golang":     "Go",
+	"csharp":     "C#",
+	"cs":         "C#",
+	"c++":        "C++",
+	"cpp":        "C++",
+	"javascript": "JavaScript",
+	"typescript": "TypeScript",
+}
+
+// languageByTag находит язык по метке блока: названию языка, псевдониму
+// или расширению без точки (py, rs, ts).
+func languageByTag(tag string) (LangConfig, bool) {
+	tag = strings.ToLower(tag)
+	if name, ok := languageAliases[tag]; ok {
+		return languageConfig(name)
+	}
+	if cfg, ok := languageConfig(tag); ok {
+		return cfg, true
+	}
+	cfg, ok := knownLanguages["."+tag]
+	return cfg, ok
+}
+
+// countEmbedded подсчитывает строки кода во встроенных блоках документа.
+// Каждый блок считается отдельно, со своим состоянием комментариев;
+// блоки на неизвестных языках пропускаются.
+func (lc *lineCounter) countEmbedded(scanner *bufio.Scanner, finder embedFinder) (fileCounts, error) {
+	var counts fileCounts
+	var st *langState
+	var name, prev string
+
+	flush := func() {
+		if st == nil {
+			return
+		}
+		if st.counts.Code > 0 {
+			if counts.Parts == nil {
+				counts.Parts = make(map[string]int)
+			}
+			counts.Parts[name] += st.counts.Code
+		}
+		counts.Code += st.counts.Code
+		counts.Imports += st.counts.Imports
+		counts.Comments += st.counts.Comments
+		counts.Blanks += st.counts.Blanks
+		st = nil
+	}
+
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		tag := finder.lang(line)
+		if tag != prev {
+			flush()
+			if cfg, ok := languageByTag(tag); ok && tag != "" {
+				st, name = newLangState(cfg), cfg.Name
+			}
+			prev = tag
+		}
+		switch {
+		case st != nil:
+			kind, use := lc.countLine(st, line)
+			lc.noteLine(n, line, st, kind, use)
+		case strings.TrimSpace(line) == "":
+			counts.Blanks++
+			lc.note(lineTrace{n: n, line: line, kind: lineBlank})
+		default:
+			// Текст документа вне блоков кода считается комментарием
+			counts.Comments++
+			lc.note(lineTrace{n: n, line: line, kind: lineComment})
+		}
+	}
+	flush()
+	return counts, scanner.Err()
+}
+
+// rstDirective — директива блока кода reStructuredText с языком.
+var rstDirective = regexp.MustCompile(`^(\s*)\.\.\s+(?:code-block|code|sourcecode)::\s*(\S+)`)
+
+// rstFinder распознаёт блоки `.. code-block:: язык`: телом блока служат
+// строки с отступом больше, чем у директивы; опции директивы (:linenos:
+// и т. п.) в блок не входят.
+type rstFinder struct {
+	tag       string // язык текущего блока ("" — вне блока)
+	indent    int    // отступ директивы
+	inOptions bool   // ещё идут опции директивы
+}
+
+func (f *rstFinder) lang(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if f.tag != "" {
+		if trimmed == "" {
+			f.inOptions = false
+			return f.tag
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent > f.indent {
+			if f.inOptions && strings.HasPrefix(trimmed, ":") {
+				return ""
+			}
+			f.inOptions = false
+			return f.tag
+		}
+		f.tag = ""
+	}
+
+	if m := rstDirective.FindStringSubmatch(line); m != nil {
+		f.tag, f.indent, f.inOptions = m[2], len(m[1]), true
+	}
+	return ""
+}
+
+// markdownFence — ограда блока кода Markdown: ``` или ~~~ и строка
+// информации, первое слово которой — язык (```go, ~~~ {.python},
+// ```rust,ignore).
+var markdownFence = regexp.MustCompile("^\\s*(`{3,}|~{3,})\\s*([^`]*)$")
+
+// markdownFinder распознаёт огороженные блоки кода Markdown. Блок
+// закрывает ограда из тех же символов не короче открывающей; блоки
+// без языка считаются текстом документа.
+type markdownFinder struct {
+	fence string // открывающая ограда ("" — вне блока)
+	tag   string // язык текущего блока
+}
+
+func (f *markdownFinder) lang(line string) string {
+	m := markdownFence.FindStringSubmatch(line)
+	if f.fence != "" {
+		if m != nil && m[1][0] == f.fence[0] && len(m[1]) >= len(f.fence) && strings.TrimSpace(m[2]) == "" {
+			f.fence = ""
+			return ""
+		}
+		return f.tag
+	}
+	if m != nil {
+		f.fence = m[1]
+		f.tag = ""
+		if info := strings.FieldsFunc(m[2], func(r rune) bool {
+			return unicode.IsSpace(r) || r == ',' || r == '}'
+		}); len(info) > 0 {
+			f.tag = strings.TrimLeft(info[0], "{.")
+		}
+	}
+	return ""
+}
+
+// Границы блока исходного кода Org-mode: #+BEGIN_SRC язык ... #+END_SRC.
+var (
+	orgBegin = regexp.MustCompile(`(?i)^\s*#\+begin_src\s+(\S+)`)
+	orgEnd   = regexp.MustCompile(`(?i)^\s*#\+end_src`)
+)
+
+// orgFinder распознаёт блоки #+BEGIN_SRC ... #+END_SRC.
+type orgFinder struct {
+	tag string // язык текущего блока ("" — вне блока)
+}
+
+func (f *orgFinder) lang(line string) string {
+	if f.tag != "" {
+		if orgEnd.MatchString(line) {
+			f.tag = ""
+			return ""
+		}
+		return f.tag
+	}
+	if m := orgBegin.FindStringSubmatch(line); m != nil {
+		f.tag = m[1]
+	}
+	return ""
+}
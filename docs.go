@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// mainSynopsis — строка использования основной команды.
+const mainSynopsis = "loc_counter [флаги] [директория | URL файла | gist:ID]"
+
+// subcommand описывает подкоманду для справки и gen-docs.
+type subcommand struct {
+	name     string
+	synopsis string
+	summary  string
+	flags    func(fs *flag.FlagSet) // nil — у подкоманды нет флагов
+}
+
+// subcommands — подкоманды в порядке вывода в документации. Флаги берутся
+// из тех же функций, что и при разборе аргументов, поэтому документация
+// не расходится с кодом.
+var subcommands = []subcommand{
+	{"age", ageSynopsis, "Распределение строк кода по возрасту последнего изменения (git blame).", func(fs *flag.FlagSet) { ageFlags(fs, &options{}) }},
+	{"history", historySynopsis, "Рост кода от релиза к релизу: подсчёт дерева на каждом теге git.", func(fs *flag.FlagSet) { historyFlags(fs) }},
+	{"explain", explainSynopsis, "Построчный разбор файла: класс каждой строки и состояние блочного комментария.", func(fs *flag.FlagSet) { explainFlags(fs, &options{priority: make(languagePriority)}) }},
+	{"install-hook", installHookSynopsis, "Установка git-хука pre-commit или pre-push, запускающего подсчёт.", func(fs *flag.FlagSet) { installHookFlags(fs) }},
+	{"org", orgSynopsis, "Подсчёт всех репозиториев организации GitHub или группы GitLab.", func(fs *flag.FlagSet) { orgFlags(fs, &options{}) }},
+	{"self-update", selfUpdateSynopsis, "Обновление утилиты до последнего релиза с проверкой контрольной суммы.", func(fs *flag.FlagSet) { selfUpdateFlags(fs) }},
+	{"gen-docs", genDocsSynopsis, "Генерация man-страницы и справки в Markdown по определениям флагов.", func(fs *flag.FlagSet) { genDocsFlags(fs) }},
+}
+
+// commandUsage возвращает функцию справки для набора флагов команды.
+// Описания флагов переводятся при выводе, когда язык уже выбран.
+func commandUsage(fs *flag.FlagSet, synopsis string) func() {
+	return func() {
+		fmt.Fprintln(fs.Output(), tr("Использование: ")+tr(synopsis))
+		fs.VisitAll(func(f *flag.Flag) { f.Usage = tr(f.Usage) })
+		fs.PrintDefaults()
+	}
+}
+
+const genDocsSynopsis = "loc_counter gen-docs [--format markdown|man] [--out файл]"
+
+// genDocsFlags объявляет флаги подкоманды gen-docs.
+func genDocsFlags(fs *flag.FlagSet) (format, out *string) {
+	format = fs.String("format", "markdown", "Формат: markdown — справка для README/сайта, man — страница руководства man(1).")
+	out = fs.String("out", "", "Записать результат в файл вместо stdout.")
+	return format, out
+}
+
+// runGenDocs реализует подкоманду gen-docs: строит справку по командной
+// строке из определений флагов основной команды и подкоманд.
+func runGenDocs(args []string) {
+	fs := flag.NewFlagSet("gen-docs", flag.ExitOnError)
+	format, out := genDocsFlags(fs)
+	fs.Usage = commandUsage(fs, genDocsSynopsis)
+	lang := langFlag(fs)
+	fs.Parse(args)
+	setLocale(*lang)
+
+	var write func(w io.Writer)
+	switch *format {
+	case "markdown":
+		write = writeMarkdownDocs
+	case "man":
+		write = writeManPage
+	default:
+		fmt.Fprintf(os.Stderr, tr("ошибка: неизвестный формат %q (ожидается markdown или man)\n"), *format)
+		os.Exit(2)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		w = bufio.NewWriter(f)
+	}
+	write(w)
+	if err := w.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+		os.Exit(1)
+	}
+}
+
+// mainFlagSet возвращает флаги основной команды для документации.
+func mainFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("loc_counter", flag.ContinueOnError)
+	var weightsFile string
+	defineFlags(fs, &options{weights: make(weightTable), priority: make(languagePriority)}, &weightsFile)
+	return fs
+}
+
+// docFlag — флаг в виде, удобном для документации.
+type docFlag struct {
+	name, arg, usage, def string
+}
+
+// docFlags перечисляет флаги набора в алфавитном порядке. Значения
+// по умолчанию, совпадающие с нулевыми, опускаются.
+func docFlags(fs *flag.FlagSet) []docFlag {
+	var flags []docFlag
+	fs.VisitAll(func(f *flag.Flag) {
+		arg, usage := flag.UnquoteUsage(f)
+		def := f.DefValue
+		switch def {
+		case "", "0", "false", "[]":
+			def = ""
+		}
+		flags = append(flags, docFlag{f.Name, arg, usage, def})
+	})
+	return flags
+}
+
+// writeMarkdownDocs выводит справку по командной строке в Markdown.
+func writeMarkdownDocs(w io.Writer) {
+	fmt.Fprintln(w, "# Справка по командной строке loc_counter")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "<!-- Сгенерировано командой `loc_counter gen-docs`; не редактируйте вручную. -->")
+
+	section := func(title, synopsis, summary string, fs *flag.FlagSet) {
+		fmt.Fprintf(w, "\n## %s\n\n", title)
+		if summary != "" {
+			fmt.Fprintf(w, "%s\n\n", summary)
+		}
+		fmt.Fprintf(w, "```\n%s\n```\n", synopsis)
+		flags := docFlags(fs)
+		if len(flags) == 0 {
+			return
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "| Флаг | По умолчанию | Описание |")
+		fmt.Fprintln(w, "|------|--------------|----------|")
+		for _, f := range flags {
+			name := "--" + f.name
+			if f.arg != "" {
+				name += " " + f.arg
+			}
+			def := ""
+			if f.def != "" {
+				def = "`" + f.def + "`"
+			}
+			usage := strings.ReplaceAll(f.usage, "|", `\|`)
+			fmt.Fprintf(w, "| `%s` | %s | %s |\n", name, def, usage)
+		}
+	}
+
+	section("loc_counter", mainSynopsis, "Подсчёт строк кода без комментариев и пустых строк.", mainFlagSet())
+	for _, c := range subcommands {
+		fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+		if c.flags != nil {
+			c.flags(fs)
+		}
+		langFlag(fs)
+		section("loc_counter "+c.name, c.synopsis, c.summary, fs)
+	}
+}
+
+// writeManPage выводит страницу руководства man(1) в формате roff.
+func writeManPage(w io.Writer) {
+	fmt.Fprintln(w, `.TH LOC_COUNTER 1 "" "loc_counter" "Руководство пользователя"`)
+	fmt.Fprintln(w, ".SH ИМЯ")
+	fmt.Fprintln(w, `loc_counter \- подсчёт строк кода без комментариев и пустых строк`)
+	fmt.Fprintln(w, ".SH СИНТАКСИС")
+	fmt.Fprintln(w, roffEscape(mainSynopsis))
+	fmt.Fprintln(w, ".SH ФЛАГИ")
+	writeManFlags(w, mainFlagSet())
+
+	fmt.Fprintln(w, ".SH ПОДКОМАНДЫ")
+	for _, c := range subcommands {
+		fmt.Fprintf(w, ".SS %s\n", c.name)
+		fmt.Fprintln(w, roffEscape(c.summary))
+		fmt.Fprintln(w, ".PP")
+		fmt.Fprintln(w, roffEscape(c.synopsis))
+		fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+		if c.flags != nil {
+			c.flags(fs)
+		}
+		langFlag(fs)
+		writeManFlags(w, fs)
+	}
+}
+
+// writeManFlags выводит флаги списком .TP.
+func writeManFlags(w io.Writer, fs *flag.FlagSet) {
+	for _, f := range docFlags(fs) {
+		fmt.Fprintln(w, ".TP")
+		line := `.B \-\-` + roffEscape(f.name)
+		if f.arg != "" {
+			line = `.BI \-\-` + roffEscape(f.name) + ` " " ` + roffEscape(f.arg)
+		}
+		fmt.Fprintln(w, line)
+		usage := f.usage
+		if f.def != "" {
+			usage += " По умолчанию: " + f.def + "."
+		}
+		fmt.Fprintln(w, roffEscape(usage))
+	}
+}
+
+// roffEscape экранирует текст для roff: обратную косую черту, дефисы
+// и точку или апостроф в начале строки, которые roff принял бы за команду.
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
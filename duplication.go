@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// lineHasher собирает хеши строк кода содержимого, прочитанного через
+// io.TeeReader, для поиска повторяющихся блоков (--duplication). Строки
+// сравниваются без отступов, комментарии и пустые строки пропускаются.
+type lineHasher struct {
+	lineWriter
+	classifier lineClassifier
+	hashes     []uint64
+}
+
+func newLineHasher(cfg LangConfig) *lineHasher {
+	h := &lineHasher{classifier: lineClassifier{cfg: cfg}}
+	h.fn = h.scanLine
+	return h
+}
+
+func (h *lineHasher) scanLine(line string) {
+	if h.classifier.classify(line) != lineCode {
+		return
+	}
+	f := fnv.New64a()
+	// Номера строк фиксированного формата (COBOL) у копий разные
+	f.Write([]byte(strings.TrimSpace(h.classifier.cfg.Fixed.text(line))))
+	h.hashes = append(h.hashes, f.Sum64())
+}
+
+// record записывает хеши строк в counts. Нулевой счётчик ничего не делает.
+func (h *lineHasher) record(counts *fileCounts) {
+	if h == nil {
+		return
+	}
+	h.flush()
+	counts.LineHashes = h.hashes
+}
+
+// windowHashes возвращает хеши всех окон из n подряд идущих строк
+// полиномиальным скользящим хешем: сдвиг окна на строку стоит O(1),
+// поэтому поиск линеен по числу строк при любом n.
+func windowHashes(lines []uint64, n int) []uint64 {
+	if len(lines) < n {
+		return nil
+	}
+	const base = 1099511628211
+	// pow = base^(n-1) — вес строки, выходящей из окна
+	var pow uint64 = 1
+	for i := 1; i < n; i++ {
+		pow *= base
+	}
+	out := make([]uint64, 0, len(lines)-n+1)
+	var h uint64
+	for i, l := range lines {
+		if i >= n {
+			h -= lines[i-n] * pow
+		}
+		h = h*base + l
+		if i >= n-1 {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// findDuplication отмечает в каждом файле отчёта строки кода, входящие
+// в блок из minLines и более строк, который встречается ещё хотя бы раз —
+// в этом же или другом файле. Хеши строк после этого освобождаются.
+func (r *report) findDuplication(minLines int) {
+	windows := make([][]uint64, len(r.files))
+	seen := make(map[uint64]int)
+	for i, f := range r.files {
+		windows[i] = windowHashes(f.lineHashes, minLines)
+		for _, h := range windows[i] {
+			seen[h]++
+		}
+	}
+	for i := range r.files {
+		f := &r.files[i]
+		dup := make([]bool, len(f.lineHashes))
+		for pos, h := range windows[i] {
+			if seen[h] > 1 {
+				for j := pos; j < pos+minLines; j++ {
+					dup[j] = true
+				}
+			}
+		}
+		for _, d := range dup {
+			if d {
+				f.duplicated++
+			}
+		}
+		f.hashedLines = len(f.lineHashes)
+		f.lineHashes = nil
+	}
+}
+
+// duplicationTotals возвращает общее число повторяющихся строк и строк,
+// среди которых их искали.
+func (r *report) duplicationTotals() (duplicated, lines int) {
+	for _, f := range r.files {
+		duplicated += f.duplicated
+		lines += f.hashedLines
+	}
+	return duplicated, lines
+}
+
+// printDuplication выводит долю повторяющегося кода по всему отчёту.
+func printDuplication(rep *report, minLines int) {
+	duplicated, lines := rep.duplicationTotals()
+	fmt.Printf(tr("Повторяющийся код (блоки от %d строк): %d из %d строк, %s\n"), minLines, duplicated, lines, formatShare(duplicated, lines))
+	fmt.Println()
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"strings"
+)
+
+// checkBackend проверяет значение --backend.
+func checkBackend(backend string) error {
+	switch backend {
+	case "heuristic", "exact":
+		return nil
+	}
+	return fmt.Errorf(tr("неизвестный способ подсчёта --backend %q (ожидается heuristic или exact)"), backend)
+}
+
+// countGoExact подсчитывает строки Go-файла по позициям токенов go/scanner
+// вместо разбора строк эвристикой: «//» и «/*» внутри строковых литералов
+// и многострочные строки в обратных кавычках учитываются правильно.
+// Строка с хотя бы одним токеном кода — код, строка только с комментарием —
+// комментарий, остальные — пустые. Возвращает false, если сканер нашёл
+// ошибку: такой файл считается эвристикой.
+func (lc *lineCounter) countGoExact(src []byte) (fileCounts, bool) {
+	var counts fileCounts
+	lines := strings.Split(string(src), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		// Перевод строки в конце файла не начинает новую строку
+		lines = lines[:len(lines)-1]
+	}
+	// Индексы с единицы, как номера строк token.File
+	code := make([]bool, len(lines)+2)
+	comment := make([]bool, len(lines)+2)
+	imports := make([]bool, len(lines)+2)
+	open := make([]openState, len(lines)+2) // конструкция, внутри которой заканчивается строка
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	errs := 0
+	var s scanner.Scanner
+	s.Init(file, src, func(token.Position, string) { errs++ }, scanner.ScanComments)
+
+	inImport := false // внутри объявления import
+	depth := 0        // скобки объявления import
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON {
+			if inImport && depth == 0 {
+				inImport = false
+			}
+			if lit == "\n" {
+				// Точка с запятой, вставленная сканером в конце строки
+				continue
+			}
+		}
+		// Сканер убирает \r из комментариев и raw-строк, поэтому конец
+		// токена считается по числу переводов строки, а не по длине
+		start := file.Line(pos)
+		end := start + strings.Count(lit, "\n")
+		switch tok {
+		case token.COMMENT:
+			for l := start; l < end; l++ {
+				open[l] = openComment
+			}
+			for l := start; l <= end; l++ {
+				comment[l] = true
+			}
+			continue
+		case token.STRING:
+			// Raw-строка в обратных кавычках на нескольких строках
+			for l := start; l < end; l++ {
+				open[l] = openString
+			}
+		case token.IMPORT:
+			inImport = true
+		case token.LPAREN:
+			if inImport {
+				depth++
+			}
+		case token.RPAREN:
+			if inImport {
+				depth--
+			}
+		}
+		for l := start; l <= end; l++ {
+			code[l] = true
+			imports[l] = imports[l] || inImport
+		}
+	}
+	if errs > 0 {
+		return fileCounts{}, false
+	}
+
+	for i, line := range lines {
+		n := i + 1
+		kind, use := lineCode, useCode
+		switch {
+		case !code[n] && strings.TrimSpace(line) == "":
+			// Пустая строка внутри блочного комментария — пустая, как в эвристике
+			counts.Blanks++
+			kind, use = lineBlank, useNone
+		case !code[n] && comment[n]:
+			counts.Comments++
+			kind, use = lineComment, useNone
+		case !code[n]:
+			// Строка без токенов, например с одной вставленной точкой с запятой
+			counts.Blanks++
+			kind, use = lineBlank, useNone
+		case lc.match != nil && !lc.match.MatchString(line):
+			use = useUnmatched
+		case lc.ignore != nil && lc.ignore.MatchString(line):
+			use = useIgnored
+		case lc.imports && imports[n]:
+			counts.Imports++
+			use = useImport
+		default:
+			counts.Code++
+		}
+		lc.note(lineTrace{n: n, line: line, kind: kind, use: use, cont: open[n-1], open: open[n]})
+	}
+	return counts, true
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const explainSynopsis = "loc_counter explain [флаги] файл"
+
+// explainFlags объявляет флаги подкоманды explain: те флаги основной
+// команды, что влияют на классификацию отдельных строк.
+func explainFlags(fs *flag.FlagSet, opts *options) {
+	fs.Func("match", "Учитывать только строки кода, подходящие под регулярное выражение.", func(v string) error {
+		re, err := regexp.Compile(v)
+		opts.match = re
+		return err
+	})
+	fs.Func("ignore-lines", "Не учитывать строки кода, подходящие под регулярное выражение.", func(v string) error {
+		re, err := regexp.Compile(v)
+		opts.ignore = re
+		return err
+	})
+	fs.BoolVar(&opts.imports, "imports", false, "Выделять строки импорта.")
+	fs.BoolVar(&opts.docCode, "doc-code", false, "Считать блоки кода в документах .md, .rst и .org.")
+	fs.BoolVar(&opts.includeConfig, "include-config", false, "Разбирать файлы конфигурации (YAML, TOML, INI, JSON).")
+	fs.BoolVar(&opts.cgo, "cgo", false, "Считать преамбулы cgo в Go-файлах кодом на C.")
+	fs.StringVar(&opts.backend, "backend", "heuristic", "Способ подсчёта Go-файлов: heuristic или exact (go/scanner).")
+	fs.StringVar(&opts.asmSyntax, "asm-syntax", "auto", "Синтаксис ассемблера: auto, gas, nasm, arm или aarch64.")
+	fs.StringVar(&opts.detect, "detect", "extension", "Определение языка: extension или content (modeline и ключевые слова).")
+	fs.Var(opts.priority, "lang-priority", "Порядок языков для неоднозначного расширения (например, --lang-priority .h=C++,C).")
+}
+
+// lineTrace — класс одной строки файла при подсчёте. Пути подсчёта
+// (эвристика, встроенные блоки, cgo, --backend exact) передают его
+// в lineCounter.trace; по нему explain выводит разбор файла.
+type lineTrace struct {
+	n    int    // номер строки, с единицы
+	line string // строка файла
+	lang string // язык фрагмента ("" — язык файла)
+	kind lineKind
+	use  lineUse
+	cont openState // конструкция, продолжающаяся с прошлой строки
+	open openState // конструкция, внутри которой строка заканчивается
+}
+
+// note передаёт класс строки в lc.trace, если он задан.
+func (lc *lineCounter) note(t lineTrace) {
+	if lc.trace != nil {
+		lc.trace(t)
+	}
+}
+
+// noteLine передаёт в lc.trace класс строки, посчитанной в состоянии st.
+func (lc *lineCounter) noteLine(n int, line string, st *langState, kind lineKind, use lineUse) {
+	if lc.trace != nil {
+		cont := st.open
+		st.open = st.classifier.open()
+		lc.trace(lineTrace{n: n, line: line, lang: st.classifier.cfg.Name, kind: kind, use: use, cont: cont, open: st.open})
+	}
+}
+
+// openMarks — отметки состояния в конце строки в выводе explain.
+var openMarks = map[openState]string{
+	openComment:   "/*",
+	openString:    `"`,
+	openDocstring: `"""`,
+	openHeredoc:   "<<",
+}
+
+// lineLabels — подписи строк в выводе explain.
+var lineLabels = map[lineUse]string{
+	useCode:      "код",
+	useImport:    "импорт",
+	useUnmatched: "не --match",
+	useIgnored:   "--ignore",
+}
+
+// runExplain реализует подкоманду explain: выводит файл построчно с классом
+// каждой строки и состоянием блочного комментария. Помогает понять, почему
+// подсчёт файла выглядит неверно, и проверить настройки нового языка.
+// Файл считается тем же путём, что и при обычном подсчёте, поэтому итоги
+// совпадают с отчётом.
+func runExplain(args []string) {
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	opts := options{priority: make(languagePriority)}
+	explainFlags(fs, &opts)
+	fs.Usage = commandUsage(fs, explainSynopsis)
+	lang := langFlag(fs)
+	fs.Parse(args)
+	setLocale(*lang)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	for _, err := range []error{checkDetect(opts.detect), checkAsmSyntax(opts.asmSyntax), checkBackend(opts.backend), opts.priority.load()} {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+			os.Exit(2)
+		}
+	}
+	path := fs.Arg(0)
+
+	ext := strings.ToLower(filepath.Ext(path))
+	cfg, ok := opts.language(filepath.Base(path), func() []byte { return fileHead(path) })
+	if !ok {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %s: язык не поддерживается (расширение %q)\n"), path, ext)
+		os.Exit(1)
+	}
+
+	f, err := os.Open(longPath(path))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	// Строку могут отметить дважды: преамбула cgo сначала считается
+	// комментарием Go, а после import "C" — кодом на C
+	var traces []lineTrace
+	lc := opts.lineCounter()
+	lc.trace = func(t lineTrace) {
+		for len(traces) < t.n {
+			traces = append(traces, lineTrace{n: len(traces) + 1})
+		}
+		traces[t.n-1] = t
+	}
+	counts, err := lc.countReader(f, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %s: %v\n"), path, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s: %s\n", path, cfg.Name)
+	fmt.Println(tr(`После строки продолжается: /* — блочный комментарий, " — многострочный литерал, """ — строка документации, << — тело heredoc.`))
+	fmt.Println()
+
+	// Столбец языка нужен, только если в файле есть фрагменты на других языках
+	langWidth := 0
+	for _, t := range traces {
+		if t.lang != "" && t.lang != cfg.Name {
+			langWidth = max(langWidth, len([]rune(t.lang)), len([]rune(cfg.Name)))
+		}
+	}
+
+	filtered := 0
+	for _, t := range traces {
+		label := tr(lineLabels[t.use])
+		switch {
+		case t.kind == lineBlank:
+			label = tr("пусто")
+		case t.kind == lineComment:
+			label = tr("комментарий")
+		case t.use == useCode && (t.cont == openString || t.cont == openHeredoc):
+			// Код, но продолжение литерала или heredoc, а не новая инструкция
+			label = tr("литерал")
+		}
+		if t.use == useUnmatched || t.use == useIgnored {
+			filtered++
+		}
+		block := openMarks[t.open]
+		if langWidth > 0 {
+			name := t.lang
+			if name == "" {
+				name = cfg.Name
+			}
+			fmt.Printf("%5d  %-11s %-3s | %-*s | %s\n", t.n, label, block, langWidth, name, t.line)
+			continue
+		}
+		fmt.Printf("%5d  %-11s %-3s | %s\n", t.n, label, block, t.line)
+	}
+
+	fmt.Println()
+	fmt.Printf(tr("Код: %d, комментарии: %d, пустые: %d"), counts.Code, counts.Comments, counts.Blanks)
+	if opts.imports {
+		fmt.Printf(tr(", импорт: %d"), counts.Imports)
+	}
+	if filtered > 0 {
+		fmt.Printf(tr(", отброшено фильтрами: %d"), filtered)
+	}
+	fmt.Println()
+	if len(counts.Parts) > 0 {
+		names := make([]string, 0, len(counts.Parts))
+		for name := range counts.Parts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts := make([]string, 0, len(names))
+		for _, name := range names {
+			parts = append(parts, fmt.Sprintf("%s %d", name, counts.Parts[name]))
+		}
+		fmt.Printf(tr("Код по языкам: %s\n"), strings.Join(parts, ", "))
+	}
+}
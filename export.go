@@ -0,0 +1,632 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// fileExt возвращает расширение файла отчёта в нижнем регистре.
+func fileExt(p string) string {
+	return strings.ToLower(path.Ext(p))
+}
+
+// byExtension возвращает итоги по расширениям файлов, отсортированные
+// по расширению.
+func (r *report) byExtension() ([]string, map[string]*subtotal) {
+	totals := make(map[string]*subtotal)
+	for _, f := range r.files {
+		ext := fileExt(f.path)
+		r.addSubtotal(totals, ext, f.lines)
+		totals[ext].imports += f.imports
+		totals[ext].comments += f.comments
+		totals[ext].blanks += f.blanks
+	}
+	exts := make([]string, 0, len(totals))
+	for ext := range totals {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts, totals
+}
+
+// jsonFile — файл в выводе --format json.
+type jsonFile struct {
+	Path      string     `json:"path"`
+	Extension string     `json:"extension"`
+	Language  string     `json:"language"`
+	Lines     int        `json:"lines"`
+	Comments  int        `json:"comments"`
+	Blanks    int        `json:"blanks"`
+	Bytes     int        `json:"bytes,omitempty"`
+	Chars     int        `json:"chars,omitempty"`
+	Tokens    int        `json:"tokens,omitempty"`
+	Todos     []todoItem `json:"todos,omitempty"`
+	Longest   int        `json:"longest,omitempty"`
+	Indent    string     `json:"indent,omitempty"`
+	Trailing  int        `json:"trailing,omitempty"`
+	// Duplicated — строки кода в повторяющихся блоках (только с --duplication)
+	Duplicated int            `json:"duplicated,omitempty"`
+	GoDecls    []goDecl       `json:"go_decls,omitempty"`
+	Imports    int            `json:"imports,omitempty"`
+	Parts      map[string]int `json:"parts,omitempty"`
+	BuildTag   string         `json:"build_tag,omitempty"`
+	Owner      string         `json:"owner,omitempty"`
+	Size       int64          `json:"size,omitempty"`
+	Modified   *time.Time     `json:"modified,omitempty"`
+	Author     string         `json:"author,omitempty"`
+	Committed  string         `json:"committed,omitempty"`
+	SHA256     string         `json:"sha256,omitempty"`
+}
+
+// jsonTotal — итог группы файлов в выводе --format json.
+type jsonTotal struct {
+	Name     string `json:"name,omitempty"`
+	Files    int    `json:"files"`
+	Lines    int    `json:"lines"`
+	Comments int    `json:"comments"`
+	Blanks   int    `json:"blanks"`
+	Imports  int    `json:"imports,omitempty"`
+	// Percent — доля строк группы от общего итога, в процентах
+	Percent float64 `json:"percent,omitempty"`
+}
+
+// jsonUnknown — нераспознанное расширение и число таких файлов (--unknown).
+type jsonUnknown struct {
+	Extension string `json:"extension"`
+	Files     int    `json:"files"`
+}
+
+// jsonError — файл, который не удалось посчитать.
+type jsonError struct {
+	Path   string `json:"path" xml:"path,attr"`
+	Reason string `json:"reason" xml:"reason,attr"`
+}
+
+// jsonDuplicate — копия файла по содержимому (--dedupe).
+type jsonDuplicate struct {
+	Path     string `json:"path"`
+	Original string `json:"original"`
+	Lines    int    `json:"lines"`
+}
+
+// jsonDuplication — итог поиска повторяющегося кода (--duplication).
+type jsonDuplication struct {
+	MinLines   int     `json:"min_lines"`
+	Lines      int     `json:"lines"`
+	Duplicated int     `json:"duplicated"`
+	Percent    float64 `json:"percent"`
+}
+
+// jsonReport — отчёт в выводе --format json.
+type jsonReport struct {
+	Files      []jsonFile  `json:"files"`
+	Extensions []jsonTotal `json:"extensions"`
+	Languages  []jsonTotal `json:"languages"`
+	Total      jsonTotal   `json:"total"`
+	Duplicates int         `json:"duplicates,omitempty"`
+	// ContentDuplicates — копии файлов по содержимому (только с --dedupe)
+	ContentDuplicates []jsonDuplicate  `json:"content_duplicates,omitempty"`
+	Duplication       *jsonDuplication `json:"duplication,omitempty"`
+	Unknown           []jsonUnknown    `json:"unknown,omitempty"`
+	// Config — файлы конфигурации по языкам, не учтённые в итогах
+	// (только с --include-config)
+	Config []jsonTotal `json:"config,omitempty"`
+	// Todos — число меток в комментариях по видам (только с --todos)
+	Todos      map[string]int `json:"todos,omitempty"`
+	Errors     []jsonError    `json:"errors"`
+	Incomplete bool           `json:"incomplete,omitempty"`
+}
+
+// percent возвращает долю part от total в процентах с одним знаком
+// после запятой.
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*1000/float64(total)) / 10
+}
+
+// newJSONFile преобразует результат файла для вывода в JSON.
+func newJSONFile(f fileResult) jsonFile {
+	jf := jsonFile{
+		Path:       f.path,
+		Extension:  fileExt(f.path),
+		Language:   f.lang,
+		Lines:      f.lines,
+		Comments:   f.comments,
+		Blanks:     f.blanks,
+		Bytes:      f.bytes,
+		Chars:      f.chars,
+		Tokens:     f.tokens,
+		Todos:      f.todos,
+		Longest:    f.longest,
+		Indent:     f.indent,
+		Trailing:   f.trailing,
+		Duplicated: f.duplicated,
+		GoDecls:    f.goDecls,
+		Imports:    f.imports,
+		Parts:      f.parts,
+		BuildTag:   f.buildTag,
+		Owner:      f.owner,
+		Size:       f.size,
+		SHA256:     f.sha256,
+	}
+	if !f.modTime.IsZero() {
+		jf.Modified = &f.modTime
+	}
+	if f.commit != nil {
+		jf.Author, jf.Committed = f.commit.author, f.commit.date
+	}
+	return jf
+}
+
+// newJSONReport собирает структуру отчёта, общую для JSON и --template:
+// файлы, итоги по расширениям и языкам, общий итог и ошибки.
+func newJSONReport(rep *report, opts *options) jsonReport {
+	out := jsonReport{
+		Files:      make([]jsonFile, 0, len(rep.files)),
+		Total:      jsonTotal{Files: len(rep.files), Lines: rep.totalLines, Comments: rep.totalComments, Blanks: rep.totalBlanks, Imports: rep.totalImports},
+		Duplicates: rep.duplicates,
+		Errors:     make([]jsonError, 0, len(rep.skipped)),
+		Incomplete: rep.incomplete,
+	}
+	for _, f := range rep.files {
+		out.Files = append(out.Files, newJSONFile(f))
+	}
+
+	exts, extTotals := rep.byExtension()
+	out.Extensions = make([]jsonTotal, 0, len(exts))
+	for _, ext := range exts {
+		t := extTotals[ext]
+		out.Extensions = append(out.Extensions, jsonTotal{ext, t.files, t.lines, t.comments, t.blanks, t.imports, percent(t.lines, rep.totalLines)})
+	}
+	langs, langTotals := rep.byLanguage()
+	out.Languages = make([]jsonTotal, 0, len(langs))
+	for _, lang := range langs {
+		t := langTotals[lang]
+		out.Languages = append(out.Languages, jsonTotal{lang, t.files, t.lines, t.comments, t.blanks, t.imports, percent(t.lines, rep.totalLines)})
+	}
+	if opts.unknown {
+		for ext, n := range rep.unknown {
+			out.Unknown = append(out.Unknown, jsonUnknown{ext, n})
+		}
+		sort.Slice(out.Unknown, func(i, j int) bool { return out.Unknown[i].Extension < out.Unknown[j].Extension })
+	}
+	if opts.todos {
+		out.Todos = rep.todoTotals(opts)
+	}
+	if opts.duplication {
+		duplicated, lines := rep.duplicationTotals()
+		out.Duplication = &jsonDuplication{opts.duplicationMin, lines, duplicated, percent(duplicated, lines)}
+	}
+	if opts.includeConfig {
+		total := rep.configTotal()
+		out.Config = make([]jsonTotal, 0, len(rep.config))
+		for _, name := range rep.configLanguageNames() {
+			t := rep.config[name]
+			out.Config = append(out.Config, jsonTotal{name, t.files, t.lines, t.comments, t.blanks, 0, percent(t.lines, total.lines)})
+		}
+	}
+	for _, d := range rep.contentDuplicates {
+		out.ContentDuplicates = append(out.ContentDuplicates, jsonDuplicate{d.path, d.original, d.lines})
+	}
+	for _, s := range rep.skipped {
+		out.Errors = append(out.Errors, jsonError{s.path, s.reason})
+	}
+	return out
+}
+
+// printJSON выводит отчёт в JSON: файлы, итоги по расширениям и языкам,
+// общий итог и файлы, которые не удалось посчитать.
+func printJSON(rep *report, opts *options) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(newJSONReport(rep, opts)); err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+	}
+}
+
+// printCSV выводит файлы и итоговую строку в CSV (RFC 4180) для импорта
+// в электронные таблицы. Разделитель и правило кавычек задаются флагами
+// --csv-delimiter и --csv-quote; по умолчанию в кавычки заключаются только
+// поля с разделителем, кавычками и переводами строк. Все числовые поля
+// целые, поэтому десятичный разделитель не настраивается.
+func printCSV(rep *report, opts *options) {
+	w := &csvWriter{w: bufio.NewWriter(os.Stdout), comma: opts.csvComma(), quoteAll: opts.csvQuote == "all"}
+	if !opts.noHeader {
+		w.write(delimitedRow(opts, []string{"path", "extension", "language", "lines"}, "todos"))
+	}
+	for _, f := range rep.files {
+		w.write(delimitedRow(opts, []string{f.path, fileExt(f.path), f.lang, strconv.Itoa(f.lines)}, strconv.Itoa(len(f.todos))))
+	}
+	w.write(delimitedRow(opts, []string{"total", "", "", strconv.Itoa(rep.totalLines)}, strconv.Itoa(rep.totalTodos())))
+	if err := w.w.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+	}
+}
+
+// checkCSVDialect проверяет значения --csv-delimiter и --csv-quote.
+func checkCSVDialect(delimiter, quote string) error {
+	if delimiter != `\t` && (utf8.RuneCountInString(delimiter) != 1 || strings.ContainsAny(delimiter, "\"\r\n")) {
+		return fmt.Errorf(tr("некорректный разделитель --csv-delimiter %q (ожидается один символ, кроме кавычки и перевода строки)"), delimiter)
+	}
+	if quote != "minimal" && quote != "all" {
+		return fmt.Errorf(tr("неизвестное значение --csv-quote %q (ожидается minimal или all)"), quote)
+	}
+	return nil
+}
+
+// csvComma возвращает разделитель полей CSV; «\t» в значении флага —
+// табуляция, которую неудобно передать в командной строке.
+func (o *options) csvComma() rune {
+	if o.csvDelimiter == "" {
+		return ','
+	}
+	if o.csvDelimiter == `\t` {
+		return '\t'
+	}
+	r, _ := utf8.DecodeRuneInString(o.csvDelimiter)
+	return r
+}
+
+// csvWriter записывает строки CSV с произвольным разделителем. В отличие
+// от encoding/csv умеет заключать в кавычки все поля (--csv-quote all),
+// чего требуют некоторые программы импорта.
+type csvWriter struct {
+	w        *bufio.Writer
+	comma    rune
+	quoteAll bool
+}
+
+func (c *csvWriter) write(row []string) {
+	for i, field := range row {
+		if i > 0 {
+			c.w.WriteRune(c.comma)
+		}
+		if c.quoteAll || c.needsQuotes(field) {
+			c.w.WriteString(`"` + strings.ReplaceAll(field, `"`, `""`) + `"`)
+		} else {
+			c.w.WriteString(field)
+		}
+	}
+	c.w.WriteString("\n")
+}
+
+// needsQuotes сообщает, нужны ли полю кавычки: как в encoding/csv — если
+// в нём есть разделитель, кавычка или перевод строки либо оно начинается
+// с пробела.
+func (c *csvWriter) needsQuotes(field string) bool {
+	if field == "" {
+		return false
+	}
+	return strings.ContainsRune(field, c.comma) || strings.ContainsAny(field, "\"\r\n") || field[0] == ' ' || field[0] == '\t'
+}
+
+// printTSV выводит файлы и итоговую строку, разделяя поля табуляцией, без
+// выравнивания и разделительных линий — для awk, cut и sort. Управляющие
+// символы в путях уже экранированы (см. escapeName), поэтому табуляция
+// и перевод строки в полях не встречаются.
+func printTSV(rep *report, opts *options) {
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	if !opts.noHeader {
+		fmt.Fprintln(w, strings.Join(delimitedRow(opts, []string{"path", "extension", "language", "lines"}, "todos"), "\t"))
+	}
+	for _, f := range rep.files {
+		fmt.Fprintln(w, strings.Join(delimitedRow(opts, []string{f.path, fileExt(f.path), f.lang, strconv.Itoa(f.lines)}, strconv.Itoa(len(f.todos))), "\t"))
+	}
+	fmt.Fprintln(w, strings.Join(delimitedRow(opts, []string{"total", "", "", strconv.Itoa(rep.totalLines)}, strconv.Itoa(rep.totalTodos())), "\t"))
+}
+
+// delimitedRow дополняет строку CSV или TSV числом меток, если задан --todos.
+func delimitedRow(opts *options, row []string, todos string) []string {
+	if opts.todos {
+		row = append(row, todos)
+	}
+	return row
+}
+
+// xmlFile — файл в выводе --format xml.
+type xmlFile struct {
+	Path      string `xml:"path,attr"`
+	Extension string `xml:"extension,attr"`
+	Language  string `xml:"language,attr"`
+	Lines     int    `xml:"lines,attr"`
+	Comments  int    `xml:"comments,attr"`
+	Blanks    int    `xml:"blanks,attr"`
+	Imports   int    `xml:"imports,attr,omitempty"`
+	Todos     int    `xml:"todos,attr,omitempty"`
+}
+
+// xmlTotal — итог группы файлов в выводе --format xml.
+type xmlTotal struct {
+	Name    string  `xml:"name,attr"`
+	Files   int     `xml:"files,attr"`
+	Lines   int     `xml:"lines,attr"`
+	Percent float64 `xml:"percent,attr"`
+}
+
+// xmlReport — отчёт в выводе --format xml. Порядок элементов не зависит
+// от числа строк: файлы идут в порядке обхода, итоги — по имени, чтобы
+// отчёты соседних сборок можно было сравнивать построчно.
+type xmlReport struct {
+	XMLName    xml.Name  `xml:"report"`
+	Incomplete bool      `xml:"incomplete,attr,omitempty"`
+	Files      []xmlFile `xml:"file"`
+	Totals     struct {
+		Files      int        `xml:"files,attr"`
+		Lines      int        `xml:"lines,attr"`
+		Comments   int        `xml:"comments,attr"`
+		Blanks     int        `xml:"blanks,attr"`
+		Imports    int        `xml:"imports,attr,omitempty"`
+		Todos      int        `xml:"todos,attr,omitempty"`
+		Languages  []xmlTotal `xml:"language"`
+		Extensions []xmlTotal `xml:"extension"`
+	} `xml:"totals"`
+	Errors []jsonError `xml:"errors>error"`
+}
+
+// printXML выводит отчёт в XML: элементы <file> и итоги <totals>
+// по языкам и расширениям.
+func printXML(rep *report, opts *options) {
+	var out xmlReport
+	out.Incomplete = rep.incomplete
+	for _, f := range rep.files {
+		out.Files = append(out.Files, xmlFile{f.path, fileExt(f.path), f.lang, f.lines, f.comments, f.blanks, f.imports, len(f.todos)})
+	}
+	out.Totals.Files = len(rep.files)
+	out.Totals.Lines = rep.totalLines
+	out.Totals.Comments = rep.totalComments
+	out.Totals.Blanks = rep.totalBlanks
+	out.Totals.Imports = rep.totalImports
+	out.Totals.Todos = rep.totalTodos()
+
+	langs, langTotals := rep.byLanguage()
+	sort.Strings(langs)
+	for _, lang := range langs {
+		out.Totals.Languages = append(out.Totals.Languages, xmlTotal{lang, langTotals[lang].files, langTotals[lang].lines, percent(langTotals[lang].lines, rep.totalLines)})
+	}
+	exts, extTotals := rep.byExtension()
+	for _, ext := range exts {
+		out.Totals.Extensions = append(out.Totals.Extensions, xmlTotal{ext, extTotals[ext].files, extTotals[ext].lines, percent(extTotals[ext].lines, rep.totalLines)})
+	}
+	for _, s := range rep.skipped {
+		out.Errors = append(out.Errors, jsonError{s.path, s.reason})
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	w.WriteString(xml.Header)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+	}
+	w.WriteString("\n")
+}
+
+// printMarkdown выводит таблицу файлов и сводку по языкам в разметке
+// GitHub Flavored Markdown — для описаний pull request и вики.
+func printMarkdown(rep *report, opts *options) {
+	if len(rep.files) == 0 {
+		fmt.Println(tr("Поддерживаемые исходные файлы не найдены."))
+		return
+	}
+	headers := []string{tr("Файл"), tr("Язык"), tr("Строки")}
+	rows := make([][]string, 0, len(rep.files))
+	for _, f := range opts.shownFiles(rep) {
+		rows = append(rows, []string{"`" + f.path + "`", f.lang, strconv.Itoa(f.lines)})
+	}
+	total := []string{fmt.Sprintf(tr("Итого (%d файлов)"), len(rep.files)), "", strconv.Itoa(rep.totalLines)}
+	if opts.todos {
+		headers = append(headers, tr("Метки"))
+		for i, f := range opts.shownFiles(rep) {
+			rows[i] = append(rows[i], strconv.Itoa(len(f.todos)))
+		}
+		total = append(total, strconv.Itoa(rep.totalTodos()))
+	}
+	printMarkdownTable(headers, rows, total)
+	printMarkdownTable(languageTable(rep, opts))
+	if opts.byExt {
+		printMarkdownTable(extensionTable(rep, opts))
+	}
+	if opts.todos {
+		fmt.Printf(tr("Метки в комментариях: %d (%s)\n"), rep.totalTodos(), strings.Join(todoTally(opts.todoMarkers(), rep.todos), ", "))
+		if opts.todosList {
+			fmt.Println()
+			for _, f := range rep.files {
+				for _, it := range f.todos {
+					fmt.Printf("- `%s:%d` %s\n", f.path, it.Line, it.Text)
+				}
+			}
+		}
+		fmt.Println()
+	}
+	if rep.incomplete {
+		fmt.Println(tr("**Отчёт неполный:** подсчёт прерван, учтены не все файлы."))
+	}
+}
+
+// ndjsonFile — строка потокового вывода о посчитанном файле.
+type ndjsonFile struct {
+	Type string `json:"type"` // всегда "file"
+	jsonFile
+}
+
+// ndjsonTotal — последняя строка потокового вывода с итогами.
+type ndjsonTotal struct {
+	Type       string         `json:"type"` // всегда "total"
+	Files      int            `json:"files"`
+	Lines      int            `json:"lines"`
+	Comments   int            `json:"comments"`
+	Blanks     int            `json:"blanks"`
+	Imports    int            `json:"imports,omitempty"`
+	Todos      map[string]int `json:"todos,omitempty"`
+	Errors     []jsonError    `json:"errors"`
+	Incomplete bool           `json:"incomplete,omitempty"`
+}
+
+// ndjsonStream возвращает получателя файлов для --format ndjson: каждый
+// файл выводится в w отдельной строкой JSON сразу после подсчёта.
+// При --repos репозитории считаются параллельно, поэтому запись
+// защищена мьютексом.
+func ndjsonStream(w io.Writer) func(fileResult) {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	return func(f fileResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		enc.Encode(ndjsonFile{"file", newJSONFile(f)})
+	}
+}
+
+// printNDJSON завершает потоковый вывод строкой с итогами: сами файлы
+// уже выведены во время подсчёта.
+func printNDJSON(rep *report, opts *options) {
+	total := ndjsonTotal{
+		Type:       "total",
+		Files:      rep.streamed + len(rep.files),
+		Lines:      rep.totalLines,
+		Comments:   rep.totalComments,
+		Blanks:     rep.totalBlanks,
+		Imports:    rep.totalImports,
+		Errors:     make([]jsonError, 0, len(rep.skipped)),
+		Incomplete: rep.incomplete,
+	}
+	if opts.todos {
+		total.Todos = rep.todoTotals(opts)
+	}
+	for _, s := range rep.skipped {
+		total.Errors = append(total.Errors, jsonError{s.path, s.reason})
+	}
+	json.NewEncoder(os.Stdout).Encode(total)
+}
+
+// tokeiNames — названия языков, которые в tokei пишутся иначе.
+var tokeiNames = map[string]string{
+	"C++":   "Cpp",
+	"C#":    "CSharp",
+	"Jinja": "Jinja2",
+	"Other": "Text",
+}
+
+// tokeiStats — счётчики строк в схеме tokei.
+type tokeiStats struct {
+	Blanks   int            `json:"blanks"`
+	Code     int            `json:"code"`
+	Comments int            `json:"comments"`
+	Blobs    map[string]int `json:"blobs"`
+}
+
+// tokeiReport — файл в схеме tokei.
+type tokeiReport struct {
+	Name  string     `json:"name"`
+	Stats tokeiStats `json:"stats"`
+}
+
+// tokeiLanguage — блок языка в схеме tokei.
+type tokeiLanguage struct {
+	Blanks     int                      `json:"blanks"`
+	Code       int                      `json:"code"`
+	Comments   int                      `json:"comments"`
+	Reports    []tokeiReport            `json:"reports"`
+	Children   map[string][]tokeiReport `json:"children"`
+	Inaccurate bool                     `json:"inaccurate"`
+}
+
+// printTokei выводит отчёт в JSON по схеме `tokei --output json`: блок на
+// каждый язык с файлами в reports и итоговый блок Total, — чтобы панели,
+// построенные вокруг tokei, принимали вывод без преобразования.
+func printTokei(rep *report, opts *options) {
+	out := make(map[string]*tokeiLanguage)
+	total := &tokeiLanguage{Reports: []tokeiReport{}, Children: make(map[string][]tokeiReport)}
+	for _, f := range rep.files {
+		name := f.lang
+		if n, ok := tokeiNames[name]; ok {
+			name = n
+		}
+		lang := out[name]
+		if lang == nil {
+			lang = &tokeiLanguage{Children: map[string][]tokeiReport{}}
+			out[name] = lang
+		}
+		// В tokei строки импорта — обычный код
+		r := tokeiReport{f.path, tokeiStats{f.blanks, f.lines + f.imports, f.comments, map[string]int{}}}
+		lang.Reports = append(lang.Reports, r)
+		lang.Blanks += r.Stats.Blanks
+		lang.Code += r.Stats.Code
+		lang.Comments += r.Stats.Comments
+
+		total.Children[name] = append(total.Children[name], r)
+		total.Blanks += r.Stats.Blanks
+		total.Code += r.Stats.Code
+		total.Comments += r.Stats.Comments
+	}
+	out["Total"] = total
+
+	if err := json.NewEncoder(os.Stdout).Encode(out); err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+	}
+}
+
+// junitTestSuites — отчёт JUnit XML, который понимают Jenkins и GitLab CI.
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// printJUnit выводит проверку лимита --max-lines в формате JUnit XML:
+// каждый файл — тест, файл сверх лимита — упавший тест. Так CI показывает
+// разрастание файлов в своём интерфейсе результатов тестов.
+func printJUnit(rep *report, opts *options) {
+	suite := junitTestSuite{Name: "loc_counter", Tests: len(rep.files)}
+	for _, f := range rep.files {
+		tc := junitTestCase{Name: f.path, Classname: "loc_counter.max_lines." + f.lang}
+		if f.lines > opts.maxLines {
+			msg := fmt.Sprintf(tr("%d строк (превышение лимита %d)"), f.lines, opts.maxLines)
+			tc.Failure = &junitFailure{Message: msg, Text: msg}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	w.WriteString(xml.Header)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+	}
+	w.WriteString("\n")
+}
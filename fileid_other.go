@@ -0,0 +1,10 @@
+//go:build !unix && !windows
+
+package main
+
+import "os"
+
+// fileIdentity на прочих платформах недоступна — дедупликация отключается.
+func fileIdentity(_ string, _ os.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
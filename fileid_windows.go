@@ -0,0 +1,34 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileIdentity возвращает пару (серийный номер тома, индекс файла).
+// FileInfo на Windows не содержит индекса, поэтому файл открывается
+// только для чтения метаданных.
+func fileIdentity(path string, _ os.FileInfo) (fileID, bool) {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return fileID{}, false
+	}
+	h, err := syscall.CreateFile(p, 0,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return fileID{}, false
+	}
+	defer syscall.CloseHandle(h)
+
+	var fi syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(h, &fi); err != nil {
+		return fileID{}, false
+	}
+	return fileID{
+		dev: uint64(fi.VolumeSerialNumber),
+		ino: uint64(fi.FileIndexHigh)<<32 | uint64(fi.FileIndexLow),
+	}, true
+}
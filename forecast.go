@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// historyPoint — подсчёт дерева на одном теге.
+type historyPoint struct {
+	tag    string
+	date   time.Time
+	byLang map[string]int // язык -> строки кода
+}
+
+// forecastDates разбирает значение --forecast: даты через запятую в виде
+// ГГГГ-ММ-ДД или сроки от сегодняшнего дня — 90d, 8w, 6m, 2y.
+func forecastDates(value string, now time.Time) ([]time.Time, error) {
+	var dates []time.Time
+	for _, s := range strings.Split(value, ",") {
+		s = strings.TrimSpace(s)
+		if d, err := time.Parse(time.DateOnly, s); err == nil {
+			dates = append(dates, d)
+			continue
+		}
+		if len(s) < 2 {
+			return nil, fmt.Errorf(tr("--forecast: некорректная дата или срок %q (ожидается ГГГГ-ММ-ДД или 90d, 8w, 6m, 2y)"), s)
+		}
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf(tr("--forecast: некорректная дата или срок %q (ожидается ГГГГ-ММ-ДД или 90d, 8w, 6m, 2y)"), s)
+		}
+		switch s[len(s)-1] {
+		case 'd':
+			dates = append(dates, now.AddDate(0, 0, n))
+		case 'w':
+			dates = append(dates, now.AddDate(0, 0, 7*n))
+		case 'm':
+			dates = append(dates, now.AddDate(0, n, 0))
+		case 'y':
+			dates = append(dates, now.AddDate(n, 0, 0))
+		default:
+			return nil, fmt.Errorf(tr("--forecast: некорректная дата или срок %q (ожидается ГГГГ-ММ-ДД или 90d, 8w, 6m, 2y)"), s)
+		}
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	return dates, nil
+}
+
+// trend — линейный тренд: строк кода в день и значение в день отсчёта.
+type trend struct {
+	slope, intercept float64
+}
+
+// fitTrend подбирает прямую по методу наименьших квадратов. Если все
+// точки в один день, тренд плоский — на уровне среднего.
+func fitTrend(xs, ys []float64) trend {
+	n := float64(len(xs))
+	var sx, sy float64
+	for i := range xs {
+		sx += xs[i]
+		sy += ys[i]
+	}
+	mx, my := sx/n, sy/n
+	var sxy, sxx float64
+	for i := range xs {
+		sxy += (xs[i] - mx) * (ys[i] - my)
+		sxx += (xs[i] - mx) * (xs[i] - mx)
+	}
+	if sxx == 0 {
+		return trend{intercept: my}
+	}
+	slope := sxy / sxx
+	return trend{slope: slope, intercept: my - slope*mx}
+}
+
+// at возвращает значение тренда в день x, не меньше нуля.
+func (t trend) at(x float64) int {
+	return max(0, int(t.slope*x+t.intercept+0.5))
+}
+
+// printForecast выводит прогноз строк кода на даты dates по линейному
+// тренду истории points: по каждому языку отдельно и в сумме. Языки,
+// которых нет на каком-то теге, считаются на нём нулём, поэтому сумма
+// прогнозов по языкам равна прогнозу по итогу.
+func printForecast(points []historyPoint, dates []time.Time) {
+	first, last := points[0], points[len(points)-1]
+	if !last.date.After(first.date) {
+		fmt.Println(tr("Для прогноза нужны хотя бы два тега с разными датами."))
+		return
+	}
+
+	day := func(d time.Time) float64 { return d.Sub(first.date).Hours() / 24 }
+	xs := make([]float64, len(points))
+	for i, p := range points {
+		xs[i] = day(p.date)
+	}
+
+	langs := make(map[string]bool)
+	for _, p := range points {
+		for lang := range p.byLang {
+			langs[lang] = true
+		}
+	}
+	names := make([]string, 0, len(langs))
+	for lang := range langs {
+		names = append(names, lang)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if last.byLang[names[i]] != last.byLang[names[j]] {
+			return last.byLang[names[i]] > last.byLang[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	headers := []string{tr("Язык"), last.tag}
+	for _, d := range dates {
+		headers = append(headers, d.Format(time.DateOnly))
+	}
+	totals := make([]int, len(dates)+1)
+	var slope float64
+	rows := make([][]string, 0, len(names))
+	for _, lang := range names {
+		ys := make([]float64, len(points))
+		for i, p := range points {
+			ys[i] = float64(p.byLang[lang])
+		}
+		t := fitTrend(xs, ys)
+		slope += t.slope
+		row := []string{lang, strconv.Itoa(last.byLang[lang])}
+		totals[0] += last.byLang[lang]
+		for i, d := range dates {
+			v := t.at(day(d))
+			totals[i+1] += v
+			row = append(row, strconv.Itoa(v))
+		}
+		rows = append(rows, row)
+	}
+	total := []string{tr("Итого")}
+	for _, v := range totals {
+		total = append(total, strconv.Itoa(v))
+	}
+
+	fmt.Printf(tr("Прогноз по линейному тренду (тегов: %d, %+.0f строк в месяц):\n"), len(points), slope*30.44)
+	printTable(headers, rows, total)
+}
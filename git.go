@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// runGit выполняет git в директории dir и возвращает его stdout.
+// В тексте ошибки приводится stderr git — он обычно объясняет причину
+// (например, «not a git repository»).
+func runGit(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// splitNul разбивает вывод git с ключом -z на отдельные пути.
+func splitNul(out []byte) []string {
+	var paths []string
+	for _, p := range strings.Split(string(out), "\x00") {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// trackedSet — файлы, известные git (индекс и HEAD), с путями относительно
+// директории подсчёта, и все директории, в которых они лежат.
+type trackedSet struct {
+	files map[string]bool
+	dirs  map[string]bool
+}
+
+// trackedFiles собирает файлы индекса (git ls-files) и коммита HEAD
+// (git ls-tree). Отсутствие HEAD (репозиторий без коммитов) ошибкой не считается.
+func trackedFiles(dir string) (*trackedSet, error) {
+	indexed, err := runGit(dir, "ls-files", "-z")
+	if err != nil {
+		return nil, err
+	}
+	paths := splitNul(indexed)
+	if committed, err := runGit(dir, "ls-tree", "-r", "-z", "--name-only", "HEAD"); err == nil {
+		paths = append(paths, splitNul(committed)...)
+	}
+
+	t := &trackedSet{files: make(map[string]bool), dirs: map[string]bool{".": true}}
+	for _, p := range paths {
+		t.files[p] = true
+		for d := path.Dir(p); d != "."; d = path.Dir(d) {
+			t.dirs[d] = true
+		}
+	}
+	return t, nil
+}
+
+// submodulePaths возвращает пути подмодулей (записи индекса с режимом 160000)
+// относительно dir. Вне git-репозитория подмодулей нет — это не ошибка.
+func submodulePaths(dir string) map[string]bool {
+	subs := make(map[string]bool)
+	out, err := runGit(dir, "ls-files", "-s", "-z")
+	if err != nil {
+		return subs
+	}
+	// Формат записи: "<режим> <хеш> <стадия>\t<путь>"
+	for _, entry := range splitNul(out) {
+		meta, p, ok := strings.Cut(entry, "\t")
+		if ok && strings.HasPrefix(meta, "160000 ") {
+			subs[p] = true
+		}
+	}
+	return subs
+}
+
+// submoduleOf возвращает подмодуль, которому принадлежит путь relPath,
+// или пустую строку, если путь лежит вне подмодулей.
+func submoduleOf(relPath string, subs map[string]bool) string {
+	for p := relPath; p != "." && p != "/"; p = path.Dir(p) {
+		if subs[p] {
+			return p
+		}
+	}
+	return ""
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// defaultGitHubAPI — адрес GitHub REST API; для GitHub Enterprise
+// задаётся флагом --github-api.
+const defaultGitHubAPI = "https://api.github.com"
+
+// githubClient обращается к GitHub REST API с необязательным токеном.
+type githubClient struct {
+	api   string
+	token string
+}
+
+// newGitHubClient создаёт клиента; пустой token берётся из $GITHUB_TOKEN.
+func newGitHubClient(api, token string) *githubClient {
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	return &githubClient{api: strings.TrimSuffix(api, "/"), token: token}
+}
+
+// get выполняет GET-запрос к пути API (или к абсолютному URL) и возвращает
+// тело ответа. Вызывающий обязан закрыть тело.
+func (c *githubClient) get(url string) (io.ReadCloser, error) {
+	if strings.HasPrefix(url, "/") {
+		url = c.api + url
+	}
+	header := http.Header{"Accept": {"application/vnd.github+json"}}
+	if c.token != "" {
+		header.Set("Authorization", "Bearer "+c.token)
+	}
+	return httpGet(url, header)
+}
+
+// httpGet выполняет GET-запрос с заголовками header и возвращает тело
+// успешного ответа. Вызывающий обязан закрыть тело.
+func httpGet(url string, header http.Header) (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if header != nil {
+		req.Header = header
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// scanGitHub подсчитывает строки кода в репозитории GitHub без клонирования:
+// архив дерева скачивается через tarball API. repo задаётся как
+// "владелец/репозиторий" с необязательной ссылкой "@ref" (ветка, тег, коммит).
+func scanGitHub(client *githubClient, repo string, opts *options) (*report, error) {
+	repo, ref, _ := strings.Cut(repo, "@")
+	if strings.Count(repo, "/") != 1 {
+		return nil, fmt.Errorf(tr("--github: ожидается владелец/репозиторий[@ref], получено %q"), repo)
+	}
+
+	url := "/repos/" + repo + "/tarball"
+	if ref != "" {
+		url += "/" + ref
+	}
+	body, err := client.get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	rep, err := scanTarball(body, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", repo, err)
+	}
+	return rep, nil
+}
+
+// scanTarball подсчитывает строки в архиве исходников (tar или tar.gz),
+// в котором всё дерево лежит в одной корневой директории, как в архивах
+// GitHub и GitLab. Корневая директория в путях отчёта отбрасывается.
+func scanTarball(r io.Reader, opts *options) (*report, error) {
+	r, err := maybeGunzip(r)
+	if err != nil {
+		return nil, err
+	}
+
+	lc := opts.lineCounter()
+	rep := opts.newReport()
+	interrupted := watchInterrupt()
+
+	contents := make(contentIndex)
+	tarReader := tar.NewReader(r)
+	for {
+		if interrupted.Load() {
+			rep.incomplete = true
+			break
+		}
+		hdr, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		_, name, ok := strings.Cut(strings.TrimPrefix(hdr.Name, "./"), "/")
+		if !ok || name == "" {
+			continue
+		}
+		switch res, kind := countTarEntry(tarReader, hdr, name, name, lc, opts, rep); kind {
+		case tarUnknown:
+			rep.addUnknown(strings.ToLower(path.Ext(name)), opts)
+		case tarCounted:
+			if contents.keep(res, rep, opts) {
+				rep.add(res)
+			}
+		}
+	}
+	return rep, nil
+}
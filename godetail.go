@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// goDecl — функция, метод или тип Go-файла и число строк кода в нём.
+type goDecl struct {
+	Name  string `json:"name"`
+	Kind  string `json:"kind"` // func, method или type
+	Line  int    `json:"line"`
+	Lines int    `json:"lines"`
+}
+
+// goDeclKinds — подписи видов объявлений в таблице.
+var goDeclKinds = map[string]string{
+	"func":   "функция",
+	"method": "метод",
+	"type":   "тип",
+}
+
+// goSource накапливает содержимое Go-файла, прочитанное через
+// io.TeeReader, чтобы после подсчёта разобрать его go/parser (--go-detail).
+type goSource struct {
+	bytes.Buffer
+}
+
+// record записывает объявления файла в counts. Файл с синтаксической
+// ошибкой разбирается до неё. Нулевой накопитель ничего не делает.
+func (s *goSource) record(counts *fileCounts) {
+	if s == nil {
+		return
+	}
+	counts.GoDecls = goDecls(s.Bytes())
+}
+
+// goDecls возвращает функции, методы и типы src в порядке объявления.
+// Строки объявления считаются так же, как строки файла: комментарии
+// и пустые строки внутри тела не учитываются.
+func goDecls(src []byte) []goDecl {
+	fset := token.NewFileSet()
+	f, _ := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if f == nil {
+		return nil
+	}
+
+	// code[i] — строка i+1 содержит код
+	classifier := lineClassifier{cfg: knownLanguages[".go"]}
+	lines := strings.Split(string(src), "\n")
+	code := make([]bool, len(lines))
+	for i, line := range lines {
+		code[i] = classifier.classify(line) == lineCode
+	}
+	codeLines := func(from, to token.Pos) int {
+		n := 0
+		for l := fset.Position(from).Line; l <= fset.Position(to).Line && l <= len(code); l++ {
+			if code[l-1] {
+				n++
+			}
+		}
+		return n
+	}
+
+	var decls []goDecl
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			decl := goDecl{Name: d.Name.Name, Kind: "func", Line: fset.Position(d.Pos()).Line, Lines: codeLines(d.Pos(), d.End())}
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				decl.Name = receiverName(d.Recv.List[0].Type) + "." + decl.Name
+				decl.Kind = "method"
+			}
+			decls = append(decls, decl)
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				ts := spec.(*ast.TypeSpec)
+				from := ts.Pos()
+				// У одиночного объявления учитывается и ключевое слово type
+				if !d.Lparen.IsValid() {
+					from = d.Pos()
+				}
+				decls = append(decls, goDecl{ts.Name.Name, "type", fset.Position(from).Line, codeLines(from, ts.End())})
+			}
+		}
+	}
+	return decls
+}
+
+// receiverName возвращает тип получателя метода так, как его пишут
+// в документации: T или (*T), без параметров типа.
+func receiverName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return "(*" + receiverName(e.X) + ")"
+	case *ast.IndexExpr:
+		return receiverName(e.X)
+	case *ast.IndexListExpr:
+		return receiverName(e.X)
+	case *ast.Ident:
+		return e.Name
+	}
+	return "?"
+}
+
+// printGoDetail выводит функции, методы и типы Go-файлов с числом строк
+// кода. Функции и методы длиннее limit строк отмечаются «!» (и красным
+// в цветном выводе) и подсчитываются отдельно.
+func printGoDetail(rep *report, limit int) {
+	var rows [][]string
+	long := 0
+	for _, f := range rep.files {
+		for _, d := range f.goDecls {
+			row := []string{fmt.Sprintf("%s:%d", f.path, d.Line), d.Name, tr(goDeclKinds[d.Kind]), strconv.Itoa(d.Lines)}
+			if limit > 0 && d.Kind != "type" && d.Lines > limit {
+				long++
+				row[3] += " !"
+				row = paintRow(ansiRed, row)
+			}
+			rows = append(rows, row)
+		}
+	}
+	if len(rows) == 0 {
+		return
+	}
+	renderTable([]string{tr("Место"), tr("Объявление"), tr("Вид"), tr("Строки")}, rows, nil, []bool{false, false, false, true})
+	if limit > 0 {
+		fmt.Printf(tr("Функций и методов длиннее %d строк: %d\n"), limit, long)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"go/build/constraint"
+	"path"
+	"strings"
+)
+
+// preambleLine — строка группы комментариев, которая может оказаться
+// преамбулой cgo.
+type preambleLine struct {
+	n       int    // номер строки в файле
+	line    string // строка файла как есть
+	text    string
+	inBlock bool // строка внутри /* */, а не комментарий //
+	opens   bool // на строке открывается /*
+	closes  bool // на строке закрывается */
+}
+
+// Значения GOOS и GOARCH, которые go build распознаёт в суффиксах имён
+// файлов (name_linux.go, name_windows_amd64.go).
+var (
+	goKnownOS = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+		"hurd": true, "illumos": true, "ios": true, "js": true, "linux": true, "nacl": true,
+		"netbsd": true, "openbsd": true, "plan9": true, "solaris": true, "wasip1": true,
+		"windows": true, "zos": true,
+	}
+	goKnownArch = map[string]bool{
+		"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true, "arm64": true,
+		"arm64be": true, "loong64": true, "mips": true, "mipsle": true, "mips64": true,
+		"mips64le": true, "mips64p32": true, "mips64p32le": true, "ppc": true, "ppc64": true,
+		"ppc64le": true, "riscv": true, "riscv64": true, "s390": true, "s390x": true,
+		"sparc": true, "sparc64": true, "wasm": true,
+	}
+)
+
+// goBuildConstraint возвращает ограничение сборки Go-файла в виде выражения
+// //go:build: из строки //go:build (или // +build) в начале файла head
+// и из суффикса имени. Пустая строка — файл собирается везде.
+func goBuildConstraint(name string, head []byte) string {
+	expr := goBuildLine(head)
+	have := map[string]bool{}
+	goAndTerms(expr, have)
+	// Теги суффикса, уже входящие в //go:build через И (file_linux_amd64.go
+	// с //go:build linux && amd64), не дублируются
+	for _, tag := range goFileSuffix(name) {
+		if have[tag] {
+			continue
+		}
+		var x constraint.Expr = &constraint.TagExpr{Tag: tag}
+		if expr != nil {
+			x = &constraint.AndExpr{X: expr, Y: x}
+		}
+		expr = x
+	}
+	if expr == nil {
+		return ""
+	}
+	return expr.String()
+}
+
+// goAndTerms собирает в set слагаемые верхнего уровня выражения,
+// соединённые через И.
+func goAndTerms(expr constraint.Expr, set map[string]bool) {
+	switch x := expr.(type) {
+	case *constraint.AndExpr:
+		goAndTerms(x.X, set)
+		goAndTerms(x.Y, set)
+	case nil:
+	default:
+		set[x.String()] = true
+	}
+}
+
+// goBuildLine разбирает ограничение сборки в комментариях перед
+// объявлением package. Строка //go:build имеет приоритет над // +build.
+func goBuildLine(head []byte) constraint.Expr {
+	var plus []constraint.Expr
+	scanner := bufio.NewScanner(bytes.NewReader(head))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "//") {
+			break
+		}
+		switch {
+		case constraint.IsGoBuild(line):
+			if expr, err := constraint.Parse(line); err == nil {
+				return expr
+			}
+		case constraint.IsPlusBuild(line):
+			if expr, err := constraint.Parse(line); err == nil {
+				plus = append(plus, expr)
+			}
+		}
+	}
+
+	// Несколько строк // +build объединяются через И
+	var expr constraint.Expr
+	for _, x := range plus {
+		if expr == nil {
+			expr = x
+		} else {
+			expr = &constraint.AndExpr{X: expr, Y: x}
+		}
+	}
+	return expr
+}
+
+// goFileSuffix возвращает теги, заданные суффиксом имени файла
+// (_GOOS, _GOARCH или _GOOS_GOARCH), по тем же правилам, что и go build.
+func goFileSuffix(name string) []string {
+	name = strings.TrimSuffix(path.Base(name), ".go")
+	name = strings.TrimSuffix(name, "_test")
+	i := strings.Index(name, "_")
+	if i < 0 {
+		return nil
+	}
+	l := strings.Split(name[i:], "_")
+	n := len(l)
+	if n >= 2 && goKnownOS[l[n-2]] && goKnownArch[l[n-1]] {
+		return []string{l[n-2], l[n-1]}
+	}
+	if goKnownOS[l[n-1]] || goKnownArch[l[n-1]] {
+		return []string{l[n-1]}
+	}
+	return nil
+}
+
+// countGo подсчитывает строки Go-файла, выделяя преамбулы cgo: группа
+// комментариев непосредственно перед import "C" — это код на C, и её строки
+// считаются по правилам C. Результат разбит по языкам в Parts.
+func (lc *lineCounter) countGo(scanner *bufio.Scanner, cfg LangConfig) (fileCounts, error) {
+	goState := newLangState(cfg)
+	var cCode, cImports int
+	var pending []preambleLine
+
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		wasInBlock := goState.classifier.inBlock()
+		kind, use := lc.countLine(goState, line)
+		lc.noteLine(n, line, goState, kind, use)
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == `import "C"`:
+			if len(pending) > 0 {
+				cState := newLangState(knownLanguages[".c"])
+				for _, p := range pending {
+					kind, use := lc.countLine(cState, p.source())
+					// Строка преамбулы уже отмечена как комментарий Go
+					lc.noteLine(p.n, p.line, cState, kind, use)
+				}
+				cCode += cState.counts.Code
+				cImports += cState.counts.Imports
+			}
+			pending = nil
+		case kind == lineComment || (kind == lineBlank && wasInBlock):
+			pending = append(pending, preambleLine{
+				n:       n,
+				line:    line,
+				text:    trimmed,
+				inBlock: wasInBlock || strings.HasPrefix(trimmed, "/*"),
+				opens:   !wasInBlock && strings.HasPrefix(trimmed, "/*"),
+				closes:  !goState.classifier.inBlock() && strings.HasSuffix(trimmed, "*/"),
+			})
+		default:
+			// Пустая строка или код разрывают группу комментариев
+			pending = nil
+		}
+	}
+
+	counts := goState.counts
+	if cCode > 0 {
+		counts.Parts = map[string]int{"Go": counts.Code, "C": cCode}
+	}
+	counts.Code += cCode
+	counts.Imports += cImports
+	counts.Comments -= cCode + cImports
+	return counts, scanner.Err()
+}
+
+// source возвращает строку преамбулы без маркеров комментария Go.
+func (p preambleLine) source() string {
+	s := p.text
+	if !p.inBlock {
+		return strings.TrimPrefix(s, "//")
+	}
+	if p.opens {
+		s = strings.TrimPrefix(s, "/*")
+	}
+	if p.closes {
+		s = strings.TrimSuffix(s, "*/")
+	}
+	return s
+}
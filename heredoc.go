@@ -0,0 +1,75 @@
+package main
+
+import "strings"
+
+// heredocSyntax — начало heredoc: <<EOF в shell, Ruby и Perl, <<<EOF в PHP.
+// После Start могут идти «-» или «~» (отступ перед концом), затем метка —
+// идентификатор, возможно в кавычках. Тело продолжается до строки,
+// которая начинается с метки, и считается кодом: «#» и «//» в нём —
+// текст, а не комментарий.
+type heredocSyntax struct {
+	Start  string
+	Spaces bool // между Start и меткой допустимы пробелы (cat << EOF)
+}
+
+// matchHeredoc разбирает начало heredoc в s и возвращает его метку
+// и длину разобранного текста или 0, если heredoc в s не начинается.
+func matchHeredoc(s string, syntaxes []heredocSyntax) (string, int) {
+	for _, h := range syntaxes {
+		if !strings.HasPrefix(s, h.Start) {
+			continue
+		}
+		i := len(h.Start)
+		if i < len(s) && s[i] == '<' {
+			// <<< после << — here-string shell, а не heredoc
+			continue
+		}
+		if i < len(s) && (s[i] == '-' || s[i] == '~') {
+			i++
+		}
+		if h.Spaces {
+			for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+				i++
+			}
+		}
+		quote := byte(0)
+		if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+			quote = s[i]
+			i++
+		}
+		start := i
+		for i < len(s) && isWordByte(s[i], i > start) {
+			i++
+		}
+		if i == start {
+			continue
+		}
+		label := s[start:i]
+		if quote != 0 {
+			if i >= len(s) || s[i] != quote {
+				continue
+			}
+			i++
+		}
+		return label, i
+	}
+	return "", 0
+}
+
+// isHeredocEnd сообщает, завершает ли line тело heredoc с меткой label.
+// Перед меткой допускается отступ (<<-, <<~ и PHP 7.3+), после неё —
+// знаки вроде «;» или «)» в PHP.
+func isHeredocEnd(line, label string) bool {
+	rest, ok := strings.CutPrefix(strings.TrimLeft(line, " \t"), label)
+	if !ok {
+		return false
+	}
+	rest = strings.TrimRight(rest, "\r")
+	return rest == "" || !isWordByte(rest[0], true)
+}
+
+// isWordByte сообщает, может ли b входить в идентификатор; цифры —
+// только не в начале.
+func isWordByte(b byte, digits bool) bool {
+	return b == '_' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || digits && '0' <= b && b <= '9'
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const historySynopsis = "loc_counter history --tags [--pattern 'v*'] [--chart-out trend.svg] [--forecast 6m,1y] [директория]"
+
+// historyFlags объявляет флаги подкоманды history.
+func historyFlags(fs *flag.FlagSet) (tags *bool, pattern, chartOut, forecast *string) {
+	tags = fs.Bool("tags", false, "Подсчитать дерево на каждом теге.")
+	pattern = fs.String("pattern", "*", "Шаблон имён тегов (например, --pattern 'v*').")
+	chartOut = fs.String("chart-out", "", "Сохранить диаграмму роста по тегам в файл SVG.")
+	forecast = fs.String("forecast", "", "Спрогнозировать строки кода по языкам на даты через запятую: ГГГГ-ММ-ДД или срок от сегодня (90d, 8w, 6m, 2y).")
+	return tags, pattern, chartOut, forecast
+}
+
+// runHistory реализует подкоманду history --tags: подсчитывает дерево
+// на каждом теге git, подходящем под шаблон, и выводит рост от релиза к релизу.
+func runHistory(args []string) {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	tags, pattern, chartOut, forecast := historyFlags(fs)
+	fs.Usage = commandUsage(fs, historySynopsis)
+	lang := langFlag(fs)
+	fs.Parse(args)
+	setLocale(*lang)
+
+	if !*tags {
+		fmt.Fprintln(os.Stderr, tr("ошибка: history поддерживает только режим --tags"))
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if *chartOut != "" {
+		if err := checkChartPath(*chartOut); err != nil {
+			fmt.Fprintf(os.Stderr, tr("ошибка: --chart-out: %v\n"), err)
+			os.Exit(2)
+		}
+	}
+
+	var dates []time.Time
+	if *forecast != "" {
+		var err error
+		if dates, err = forecastDates(*forecast, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+			os.Exit(2)
+		}
+	}
+
+	dir := "."
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+
+	// Теги в порядке создания: "<тег>\x00<дата>" в каждой строке
+	out, err := runGit(dir, "for-each-ref", "--sort=creatordate",
+		"--format=%(refname:short)%00%(creatordate:short)", "refs/tags/"+*pattern)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+		os.Exit(1)
+	}
+
+	var rows [][]string
+	var bars []chartBar
+	var points []historyPoint
+	prev := -1
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		tag, date, ok := strings.Cut(line, "\x00")
+		if !ok {
+			continue
+		}
+
+		files, byLang, err := countTreeAt(dir, tag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, tr("предупреждение: %s: %v\n"), tag, err)
+			continue
+		}
+		lines := 0
+		for _, n := range byLang {
+			lines += n
+		}
+		if d, err := time.Parse(time.DateOnly, date); err == nil {
+			points = append(points, historyPoint{tag, d, byLang})
+		}
+
+		change := ""
+		if prev >= 0 {
+			change = fmt.Sprintf("%+d", lines-prev)
+		}
+		prev = lines
+		rows = append(rows, []string{tag, date, strconv.Itoa(files), strconv.Itoa(lines), change})
+		bars = append(bars, chartBar{tag, lines})
+	}
+
+	if len(rows) == 0 {
+		fmt.Printf(tr("Теги, подходящие под шаблон %q, не найдены.\n"), *pattern)
+		return
+	}
+
+	fmt.Println()
+	printTable([]string{tr("Тег"), tr("Дата"), tr("Файлы"), tr("Строки"), tr("Изменение")}, rows, nil)
+
+	if len(dates) > 0 {
+		printForecast(points, dates)
+	}
+
+	if *chartOut != "" {
+		if err := writeChart(*chartOut, tr("Строки кода по релизам"), bars); err != nil {
+			fmt.Fprintf(os.Stderr, tr("ошибка сохранения диаграммы: %v\n"), err)
+			os.Exit(1)
+		}
+	}
+}
+
+// countTreeAt подсчитывает поддерживаемые файлы дерева на ревизии rev,
+// не трогая рабочую копию: содержимое читается потоком из git archive.
+// Возвращает число файлов и строки кода по языкам.
+func countTreeAt(dir, rev string) (files int, byLang map[string]int, err error) {
+	cmd := exec.Command("git", "-C", dir, "archive", "--format=tar", rev)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return 0, nil, err
+	}
+
+	files, byLang, err = countTar(stdout)
+	// Дочитываем остаток, чтобы git не завис на записи в закрытый канал
+	io.Copy(io.Discard, stdout)
+	if waitErr := cmd.Wait(); waitErr != nil && err == nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return 0, nil, fmt.Errorf("git archive: %s", msg)
+		}
+		return 0, nil, waitErr
+	}
+	return files, byLang, err
+}
+
+// countTar подсчитывает строки кода по языкам во всех поддерживаемых
+// файлах tar-потока.
+func countTar(r io.Reader) (files int, byLang map[string]int, err error) {
+	byLang = make(map[string]int)
+	tarReader := tar.NewReader(r)
+	for {
+		hdr, err := tarReader.Next()
+		if err == io.EOF {
+			return files, byLang, nil
+		}
+		if err != nil {
+			return files, byLang, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		cfg, ok := languageByName(path.Base(hdr.Name))
+		if !ok {
+			continue
+		}
+
+		var lc lineCounter
+		counts, err := lc.countReader(tarReader, cfg)
+		if err != nil {
+			return files, byLang, fmt.Errorf("%s: %w", hdr.Name, err)
+		}
+		files++
+		byLang[cfg.Name] += counts.Code
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// hookScripts — тела git-хуков по их имени. %s заменяется командой запуска
+// утилиты с дополнительными аргументами, "$@" — флагом --args-file
+// с настройками репозитория (см. hookPrelude). Хуки git запускает из корня
+// рабочей копии. С --fail-over утилита завершается с кодом 3 при
+// превышении лимита, и git отменяет коммит или отправку.
+var hookScripts = map[string]string{
+	// Перед коммитом считаем только индексированные изменения; код
+	// завершения конвейера — код утилиты
+	"pre-commit": "git diff --cached --no-color | %s --fail-over \"$@\" --patch -\n",
+	// Перед отправкой считаем всё, что находится под контролем версий
+	"pre-push": "exec %s --fail-over \"$@\" --tracked .\n",
+}
+
+// hookArgsFile — файл с флагами утилиты в корне репозитория. Его коммитят
+// вместе с кодом, чтобы хук у всех участников проверял одни и те же лимиты
+// и фильтры, а менять их можно было без переустановки хука.
+const hookArgsFile = ".loc_counter"
+
+// hookPrelude подставляет в "$@" флаг --args-file, если в репозитории есть
+// hookArgsFile. Аргументы, которые git передаёт pre-push (имя и адрес
+// удалённого репозитория), при этом отбрасываются.
+const hookPrelude = "if [ -f " + hookArgsFile + " ]; then set -- --args-file " + hookArgsFile + "; else set --; fi\n"
+
+// hookLimits — флаги лимитов, которые проверяет хук: без них хук только
+// выводит отчёт.
+var hookLimits = map[string]string{
+	"pre-commit": "--max-added",
+	"pre-push":   "--max-lines",
+}
+
+const installHookSynopsis = "loc_counter install-hook [флаги] [pre-commit|pre-push] [-- аргументы loc_counter]"
+
+// installHookFlags объявляет флаги подкоманды install-hook.
+func installHookFlags(fs *flag.FlagSet) (force *bool, repo *string) {
+	force = fs.Bool("force", false, "Перезаписать существующий хук.")
+	repo = fs.String("repo", ".", "Путь к git-репозиторию.")
+	return force, repo
+}
+
+// runInstallHook реализует подкоманду install-hook: записывает git-хук,
+// запускающий утилиту. Аргументы после имени хука передаются утилите.
+func runInstallHook(args []string) {
+	fs := flag.NewFlagSet("install-hook", flag.ExitOnError)
+	force, repo := installHookFlags(fs)
+	fs.Usage = commandUsage(fs, installHookSynopsis)
+	lang := langFlag(fs)
+	fs.Parse(args)
+	setLocale(*lang)
+
+	hook := "pre-commit"
+	extra := fs.Args()
+	if len(extra) > 0 && extra[0] != "--" {
+		hook, extra = extra[0], extra[1:]
+	}
+	if len(extra) > 0 && extra[0] == "--" {
+		extra = extra[1:]
+	}
+
+	body, ok := hookScripts[hook]
+	if !ok {
+		fmt.Fprintf(os.Stderr, tr("ошибка: неизвестный хук %q (ожидается pre-commit или pre-push)\n"), hook)
+		os.Exit(2)
+	}
+
+	// Каталог хуков с учётом core.hooksPath и рабочих деревьев
+	out, err := runGit(*repo, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+		os.Exit(1)
+	}
+	hooksDir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(hooksDir) {
+		hooksDir = filepath.Join(*repo, hooksDir)
+	}
+	hookPath := filepath.Join(hooksDir, hook)
+
+	if _, err := os.Stat(hookPath); err == nil && !*force {
+		fmt.Fprintf(os.Stderr, tr("ошибка: хук %s уже существует (используйте --force для перезаписи)\n"), hookPath)
+		os.Exit(1)
+	}
+
+	// Хук вызывает именно этот исполняемый файл, чтобы не зависеть от PATH
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "loc_counter"
+	}
+	command := shellQuote(filepath.ToSlash(exe))
+	for _, a := range extra {
+		command += " " + shellQuote(a)
+	}
+
+	script := "#!/bin/sh\n# Установлено командой loc_counter install-hook\n" + hookPrelude + fmt.Sprintf(body, command)
+	if err := os.MkdirAll(hooksDir, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(hookPath, []byte(script), 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+		os.Exit(1)
+	}
+	fmt.Printf(tr("Хук %s установлен: %s\n"), hook, hookPath)
+	// Лимит может быть задан и в закоммиченном файле настроек
+	var committed []string
+	if top, err := runGit(*repo, "rev-parse", "--show-toplevel"); err == nil {
+		committed, _ = readArgsFile(filepath.Join(strings.TrimSpace(string(top)), hookArgsFile))
+	}
+	if !hasFlag(extra, hookLimits[hook]) && !hasFlag(committed, hookLimits[hook]) {
+		fmt.Fprintf(os.Stderr, tr("предупреждение: лимит не задан, хук будет только выводить отчёт (например: install-hook %s -- %s 500)\n"), hook, hookLimits[hook])
+	}
+}
+
+// hasFlag сообщает, есть ли среди аргументов флаг name (в любой записи:
+// --name N, --name=N, -name N).
+func hasFlag(args []string, name string) bool {
+	bare := strings.TrimLeft(name, "-")
+	for _, a := range args {
+		a, _, _ = strings.Cut(a, "=")
+		if strings.TrimLeft(a, "-") == bare && strings.HasPrefix(a, "-") {
+			return true
+		}
+	}
+	return false
+}
+
+// shellQuote заключает строку в одинарные кавычки для POSIX sh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// markupConfig возвращает конфигурацию разметки, в которой встречаются
+// блоки <script> и <style>: HTML, однофайловые компоненты Vue и Svelte.
+// Строки разметки считаются как HTML, содержимое блоков — на языке блока,
+// и в сводке по языкам каждая часть попадает в свой язык.
+func markupConfig(name string) LangConfig {
+	cfg := xmlConfig(name)
+	cfg.Embedded = func() embedFinder { return &htmlFinder{} }
+	return cfg
+}
+
+// xmlConfig возвращает конфигурацию разметки с комментариями <!-- -->.
+// «<!--» внутри значения атрибута не начинает комментарий, а значения
+// атрибутов могут продолжаться на следующих строках.
+func xmlConfig(name string) LangConfig {
+	return LangConfig{
+		Name:       name,
+		MultiStart: "<!--",
+		MultiEnd:   "-->",
+		MultilineStrings: []multilineString{
+			{Start: `"`, End: `"`},
+			{Start: "'", End: "'"},
+		},
+		Markup: true,
+	}
+}
+
+var (
+	// htmlOpen — открывающий тег блока; «>» может быть на следующих строках
+	htmlOpen = regexp.MustCompile(`(?i)<(script|style)\b([^>]*)(>?)`)
+	// htmlAttr — атрибут lang или type открывающего тега
+	htmlAttr = regexp.MustCompile(`(?i)\b(lang|type)\s*=\s*["']?([\w/+.-]+)`)
+)
+
+// htmlFinder распознаёт блоки <script> и <style>. Строки с тегами
+// относятся к разметке, строки между ними — к языку блока.
+type htmlFinder struct {
+	element string // открытый элемент: script или style ("" — разметка)
+	attrs   string // атрибуты открывающего тега, пока он не закрыт «>»
+	opening bool   // открывающий тег продолжается на следующей строке
+	tag     string // язык текущего блока
+}
+
+func (f *htmlFinder) lang(line string) string {
+	if f.opening {
+		end := strings.IndexByte(line, '>')
+		if end < 0 {
+			f.attrs += " " + line
+			return "html"
+		}
+		f.opening = false
+		f.open(f.attrs + " " + line[:end])
+		return "html"
+	}
+	if f.element != "" {
+		if strings.Contains(strings.ToLower(line), "</"+f.element) {
+			f.element = ""
+			return "html"
+		}
+		return f.tag
+	}
+
+	m := htmlOpen.FindStringSubmatchIndex(line)
+	if m == nil {
+		return "html"
+	}
+	element := strings.ToLower(line[m[2]:m[3]])
+	if strings.Contains(strings.ToLower(line[m[1]:]), "</"+element) {
+		// Блок целиком на одной строке — строка разметки
+		return "html"
+	}
+	f.element = element
+	if m[6] == m[7] {
+		// Тег не закрыт «>» на этой строке
+		f.opening, f.attrs = true, line[m[4]:m[5]]
+		return "html"
+	}
+	f.open(line[m[4]:m[5]])
+	return "html"
+}
+
+// open определяет язык блока по атрибутам открывающего тега:
+// <script lang="ts">, <style lang="scss">, <script type="text/typescript">.
+// Блоки данных и шаблонов (<script type="application/json">,
+// type="text/x-template") считаются разметкой.
+func (f *htmlFinder) open(attrs string) {
+	f.tag = "javascript"
+	if f.element == "style" {
+		f.tag = "css"
+	}
+	for _, m := range htmlAttr.FindAllStringSubmatch(attrs, -1) {
+		value := strings.ToLower(m[2])
+		switch {
+		case strings.EqualFold(m[1], "lang"):
+			f.tag = value
+		case value == "module" || strings.HasSuffix(value, "javascript") || strings.HasSuffix(value, "ecmascript"):
+			f.tag = "javascript"
+		case strings.HasSuffix(value, "typescript"):
+			f.tag = "typescript"
+		case value == "text/css":
+			f.tag = "css"
+		default:
+			f.tag = "html"
+		}
+	}
+}
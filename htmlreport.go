@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// htmlFile — файл в HTML-отчёте. Шаблону доступны только
+// экспортируемые поля, поэтому fileResult не передаётся напрямую.
+type htmlFile struct {
+	Path, Language string
+	Lines          int
+}
+
+// htmlDir — директория отчёта с её файлами.
+type htmlDir struct {
+	Name  string
+	Lines int
+	Files []htmlFile
+}
+
+// htmlLanguage — строка сводки по языкам.
+type htmlLanguage struct {
+	Name         string
+	Files, Lines int
+	Share        string
+	Color        string
+}
+
+// htmlTodo — метка в комментарии для раздела --todos.
+type htmlTodo struct {
+	Path string
+	todoItem
+}
+
+// htmlData — данные шаблона HTML-отчёта.
+type htmlData struct {
+	Created    string
+	Root       string
+	Files      int
+	Lines      int
+	Incomplete bool
+	Pie        template.HTML
+	Languages  []htmlLanguage
+	Dirs       []htmlDir
+	Skipped    []jsonError
+
+	// Todos и TodoTally — метки в комментариях (только с --todos)
+	Todos     []htmlTodo
+	TodoTally string
+}
+
+// printHTML выводит отчёт одной HTML-страницей без внешних зависимостей:
+// сводка по языкам с круговой диаграммой и сворачиваемые разделы
+// по директориям. Таблицы сортируются щелчком по заголовку столбца.
+func printHTML(rep *report, opts *options) {
+	data := htmlData{
+		Created:    time.Now().Format("2006-01-02 15:04"),
+		Root:       rep.root,
+		Files:      len(rep.files),
+		Lines:      rep.totalLines,
+		Incomplete: rep.incomplete,
+	}
+	for _, sk := range rep.skipped {
+		data.Skipped = append(data.Skipped, jsonError{sk.path, sk.reason})
+	}
+
+	langs, totals := rep.byLanguage()
+	bars := make([]chartBar, 0, len(langs))
+	for i, lang := range langs {
+		t := totals[lang]
+		bars = append(bars, chartBar{lang, t.lines})
+		data.Languages = append(data.Languages, htmlLanguage{
+			Name:  lang,
+			Files: t.files,
+			Lines: t.lines,
+			Share: formatShare(t.lines, rep.totalLines),
+			Color: chartColors[i%len(chartColors)],
+		})
+	}
+	var pie strings.Builder
+	writePieChartSVG(&pie, bars)
+	data.Pie = template.HTML(pie.String())
+
+	dirs := make(map[string]*htmlDir)
+	for _, f := range rep.files {
+		name := path.Dir(f.path)
+		if dirs[name] == nil {
+			dirs[name] = &htmlDir{Name: name}
+		}
+		dirs[name].Files = append(dirs[name].Files, htmlFile{f.path, f.lang, f.lines})
+		dirs[name].Lines += f.lines
+	}
+	for _, d := range dirs {
+		data.Dirs = append(data.Dirs, *d)
+	}
+	sort.Slice(data.Dirs, func(i, j int) bool { return data.Dirs[i].Name < data.Dirs[j].Name })
+
+	if opts.todos {
+		data.TodoTally = fmt.Sprintf("%d (%s)", rep.totalTodos(), strings.Join(todoTally(opts.todoMarkers(), rep.todos), ", "))
+		for _, f := range rep.files {
+			for _, it := range f.todos {
+				data.Todos = append(data.Todos, htmlTodo{f.path, it})
+			}
+		}
+	}
+
+	if err := htmlTemplate.Execute(os.Stdout, data); err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+	}
+}
+
+// formatShare возвращает долю part от total в процентах.
+func formatShare(part, total int) string {
+	if total == 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(part)*100/float64(total))
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"tr":     tr,
+	"locale": func() string { return locale },
+}).Parse(`<!DOCTYPE html>
+<html lang="{{locale}}">
+<head>
+<meta charset="utf-8">
+<title>{{tr "Строки кода"}}{{if .Root}}: {{.Root}}{{end}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin: 0.5em 0 1em; }
+th, td { padding: 0.25em 0.75em; border-bottom: 1px solid #ddd; text-align: left; }
+td.num, th.num { text-align: right; }
+th { cursor: pointer; user-select: none; background: #f4f4f4; }
+th.asc::after { content: " ▲"; } th.desc::after { content: " ▼"; }
+summary { cursor: pointer; padding: 0.25em 0; }
+.swatch { display: inline-block; width: 0.8em; height: 0.8em; margin-right: 0.4em; }
+.warn { color: #b00; font-weight: bold; }
+.langs { display: flex; gap: 2em; align-items: flex-start; flex-wrap: wrap; }
+</style>
+</head>
+<body>
+<h1>{{tr "Строки кода"}}{{if .Root}}: {{.Root}}{{end}}</h1>
+<p>{{printf (tr "Файлов: %d, строк кода: %d. Отчёт создан %s.") .Files .Lines .Created}}</p>
+{{if .Incomplete}}<p class="warn">{{tr "Отчёт неполный: подсчёт прерван, учтены не все файлы."}}</p>{{end}}
+
+<h2>{{tr "Языки"}}</h2>
+<div class="langs">
+<table class="sortable">
+<thead><tr><th>{{tr "Язык"}}</th><th class="num">{{tr "Файлы"}}</th><th class="num">{{tr "Строки"}}</th><th class="num">{{tr "Доля"}}</th></tr></thead>
+<tbody>
+{{range .Languages}}<tr><td><span class="swatch" style="background: {{.Color}}"></span>{{.Name}}</td><td class="num">{{.Files}}</td><td class="num">{{.Lines}}</td><td class="num">{{.Share}}</td></tr>
+{{end}}</tbody>
+</table>
+{{.Pie}}
+</div>
+
+<h2>{{tr "Директории"}}</h2>
+{{range .Dirs}}<details>
+<summary>{{.Name}} — {{printf (tr "файлов: %d, строк: %d") (len .Files) .Lines}}</summary>
+<table class="sortable">
+<thead><tr><th>{{tr "Файл"}}</th><th>{{tr "Язык"}}</th><th class="num">{{tr "Строки"}}</th></tr></thead>
+<tbody>
+{{range .Files}}<tr><td>{{.Path}}</td><td>{{.Language}}</td><td class="num">{{.Lines}}</td></tr>
+{{end}}</tbody>
+</table>
+</details>
+{{end}}
+{{if .TodoTally}}<h2>{{tr "Метки в комментариях"}}</h2>
+<p>{{printf (tr "Всего: %s.") .TodoTally}}</p>
+{{if .Todos}}<table class="sortable">
+<thead><tr><th>{{tr "Файл"}}</th><th class="num">{{tr "Строка"}}</th><th>{{tr "Метка"}}</th><th>{{tr "Текст"}}</th></tr></thead>
+<tbody>
+{{range .Todos}}<tr><td>{{.Path}}</td><td class="num">{{.Line}}</td><td>{{.Marker}}</td><td>{{.Text}}</td></tr>
+{{end}}</tbody>
+</table>
+{{end}}{{end}}
+{{if .Skipped}}<h2>{{tr "Пропущенные файлы"}}</h2>
+<ul>
+{{range .Skipped}}<li>{{.Path}}: {{.Reason}}</li>
+{{end}}</ul>
+{{end}}
+<script>
+document.querySelectorAll("table.sortable th").forEach(function (th) {
+  th.addEventListener("click", function () {
+    var table = th.closest("table"), body = table.tBodies[0];
+    var col = Array.prototype.indexOf.call(th.parentNode.children, th);
+    var asc = !th.classList.contains("asc");
+    table.querySelectorAll("th").forEach(function (h) { h.classList.remove("asc", "desc"); });
+    th.classList.add(asc ? "asc" : "desc");
+    var num = th.classList.contains("num");
+    var rows = Array.prototype.slice.call(body.rows);
+    rows.sort(function (a, b) {
+      var x = a.cells[col].textContent, y = b.cells[col].textContent;
+      var d = num ? parseFloat(x) - parseFloat(y) : x.localeCompare(y);
+      return asc ? d : -d;
+    });
+    rows.forEach(function (r) { body.appendChild(r); });
+  });
+});
+</script>
+</body>
+</html>
+`))
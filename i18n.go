@@ -0,0 +1,456 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// locale — язык сообщений отчёта (--lang): ru или en.
+var locale = "ru"
+
+// catalogs — переводы сообщений по языкам. Ключ — исходная русская строка
+// (как в gettext), поэтому строка без перевода выводится по-русски.
+var catalogs = map[string]map[string]string{
+	"en": enMessages,
+}
+
+// tr возвращает перевод msg на язык locale.
+func tr(msg string) string {
+	if s, ok := catalogs[locale][msg]; ok {
+		return s
+	}
+	return msg
+}
+
+// checkLocale проверяет значение --lang.
+func checkLocale(lang string) error {
+	switch lang {
+	case "ru", "en":
+		return nil
+	}
+	return fmt.Errorf(tr("неизвестный язык --lang %q (ожидается en или ru)"), lang)
+}
+
+// langFlag объявляет флаг --lang в наборе флагов подкоманды.
+func langFlag(fs *flag.FlagSet) *string {
+	return fs.String("lang", "", "Язык сообщений: en или ru. По умолчанию: по LC_ALL, LC_MESSAGES или LANG.")
+}
+
+// argsLocale находит значение --lang в аргументах командной строки до их
+// разбора: язык нужен раньше, чем flag выведет справку или ошибку значения.
+// Пустая строка — флаг не задан.
+func argsLocale(args []string) string {
+	return argsValue(args, "lang")
+}
+
+// argsValue находит значение флага flagName в аргументах командной строки
+// до их разбора. Пустая строка — флаг не задан.
+func argsValue(args []string, flagName string) string {
+	for i, arg := range args {
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if name != flagName {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// setLocale выбирает язык сообщений из --lang; пустое значение оставляет
+// язык окружения. Неизвестный язык завершает программу с кодом 2.
+func setLocale(lang string) {
+	if lang == "" {
+		return
+	}
+	if err := checkLocale(lang); err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+		os.Exit(2)
+	}
+	locale = lang
+}
+
+// defaultLocale выбирает язык по переменным окружения LC_ALL, LC_MESSAGES
+// и LANG, как это делают утилиты POSIX: русский для ru_*, английский для
+// любой другой локали (в том числе C и POSIX в CI). Если переменные
+// не заданы, остаётся русский.
+func defaultLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		v := os.Getenv(name)
+		if v == "" {
+			continue
+		}
+		if strings.HasPrefix(v, "ru") {
+			return "ru"
+		}
+		return "en"
+	}
+	return "ru"
+}
+
+var enMessages = map[string]string{
+	// Таблицы
+	"Файл":                  "File",
+	"Файлы":                 "Files",
+	"Строки":                "Lines",
+	"Итого":                 "Total",
+	"Итого (%d файлов)":     "Total (%d files)",
+	"Язык":                  "Language",
+	"Среднее":               "Average",
+	"Импорты":               "Imports",
+	"Вес":                   "Weight",
+	"Нормировано":           "Normalized",
+	"Байт":                  "Bytes",
+	"Изменён":               "Modified",
+	"Автор":                 "Author",
+	"Коммит":                "Commit",
+	"Расширение":            "Extension",
+	"Комментарии":           "Comments",
+	"Пустые":                "Blank",
+	"Всего":                 "All",
+	"Доля":                  "Share",
+	"(без расширения)":      "(no extension)",
+	"Символы":               "Chars",
+	"Токены":                "Tokens",
+	"Плотность":             "Density",
+	"Директория":            "Directory",
+	"Репозиторий":           "Repository",
+	"Подмодуль":             "Submodule",
+	"Модуль":                "Module",
+	"(вне модулей)":         "(outside modules)",
+	"Владелец":              "Owner",
+	"(без владельца)":       "(no owner)",
+	"Ограничение сборки Go": "Go build constraint",
+	"(без ограничений)":     "(unconstrained)",
+	"Нераспознанное расширение": "Unrecognized extension",
+	"Метки":   "Markers",
+	"Повторы": "Duplicated",
+
+	// Отчёт
+	"Поддерживаемые исходные файлы не найдены.":                                                         "No supported source files found.",
+	"ОТЧЁТ НЕПОЛНЫЙ: подсчёт прерван, учтены не все файлы.":                                             "INCOMPLETE REPORT: counting was interrupted, not all files were counted.",
+	"Повторные жёсткие ссылки не учтены: %d\n":                                                          "Duplicate hard links not counted: %d\n",
+	"Строк C в преамбулах cgo: %d (файлов: %d)\n":                                                       "C lines in cgo preambles: %d (files: %d)\n",
+	"Нормированный итог: %.1f\n":                                                                        "Normalized total: %.1f\n",
+	"Файлов сверх лимита %d строк: %d\n":                                                                "Files over the %d-line limit: %d\n",
+	"Пропущено файлов: %d\n":                                                                            "Skipped files: %d\n",
+	"%s:1: %d строк (превышение лимита %d)\n":                                                           "%s:1: %d lines (over the limit of %d)\n",
+	"Запуск сохранён в %s: файлов %d, строк %d.\n":                                                      "Run saved to %s: %d files, %d lines.\n",
+	"%d строк (превышение лимита %d)":                                                                   "%d lines (over the limit of %d)",
+	"**Отчёт неполный:** подсчёт прерван, учтены не все файлы.":                                         "**Incomplete report:** counting was interrupted, not all files were counted.",
+	"Показаны %d крупнейших файлов из %d\n":                                                             "Showing the %d largest of %d files\n",
+	"Оценка COCOMO (базовая модель, %s):\n":                                                             "COCOMO estimate (basic model, %s):\n",
+	"  Трудоёмкость:  %.1f человеко-месяцев\n":                                                          "  Effort:        %.1f person-months\n",
+	"  Срок:          %.1f месяцев\n":                                                                   "  Schedule:      %.1f months\n",
+	"  Команда:       %.1f человек\n":                                                                   "  People:        %.1f\n",
+	"  Стоимость:     %s (зарплата %s в год, накладные ×%s)\n":                                          "  Cost:          %s (salary %s per year, overhead ×%s)\n",
+	"неизвестная модель COCOMO %q (ожидается organic, semi-detached или embedded)":                      "unknown COCOMO model %q (expected organic, semi-detached or embedded)",
+	"Метки в комментариях: %d (%s)\n":                                                                   "Comment markers: %d (%s)\n",
+	"Копии файлов по содержимому: %d, строк: %d (не учтены в итогах)\n":                                 "Content duplicates: %d, lines: %d (not counted in totals)\n",
+	"Конфигурация (не учтена в итогах кода): файлов %d, строк %d\n":                                     "Configuration (not included in code totals): files %d, lines %d\n",
+	"IDL/схемы: файлов %d, строк %d (%s от итога)\n\n":                                                  "IDL/schema: %d files, %d lines (%s of total)\n\n",
+	"Строк конфигурации на 100 строк кода: %.1f\n":                                                      "Configuration lines per 100 lines of code: %.1f\n",
+	"Копии файлов по содержимому: %d, строк: %d\n":                                                      "Content duplicates: %d, lines: %d\n",
+	"Повторяющийся код (блоки от %d строк): %d из %d строк, %s\n":                                       "Duplicated code (blocks of %d+ lines): %d of %d lines, %s\n",
+	"Функций и методов длиннее %d строк: %d\n":                                                          "Functions and methods over %d lines: %d\n",
+	"Введите путь к директории [.]: ":                                                                   "Enter a directory path [.]: ",
+	"Строки кода по языкам":                                                                             "Lines of code by language",
+	"Чтобы учитывать их, сопоставьте расширение языку (например, --lang-priority .jsm=JavaScript)":      "To count them, map the extension to a language (for example, --lang-priority .jsm=JavaScript)",
+	"или добавьте язык в knownLanguages (см. README); --count-unknown учтёт текстовые файлы как Other.": "or add the language to knownLanguages (see README); --count-unknown counts text files as Other.",
+
+	// Ошибки
+	"ошибка: %v\n":                                        "error: %v\n",
+	"ошибка: --weights-file: %v\n":                        "error: --weights-file: %v\n",
+	"ошибка: --chart-out: %v\n":                           "error: --chart-out: %v\n",
+	"ошибка: --format sqlite: %v\n":                       "error: --format sqlite: %v\n",
+	"ошибка: --template: %v\n":                            "error: --template: %v\n",
+	"ошибка сохранения манифеста: %v\n":                   "error saving manifest: %v\n",
+	"ошибка сохранения диаграммы: %v\n":                   "error saving chart: %v\n",
+	"ошибка сохранения отчёта %s: %v\n":                   "error saving report %s: %v\n",
+	"предупреждение: не удалось понизить приоритет: %v\n": "warning: could not lower priority: %v\n",
+	"ошибка: недопустимое значение --submodules %q (ожидается skip, include или only)\n":                                            "error: invalid --submodules value %q (expected skip, include or only)\n",
+	"ошибка: --image нельзя сочетать с директорией, --patch, --tracked и --checkpoint":                                              "error: --image cannot be combined with a directory, --patch, --tracked or --checkpoint",
+	"ошибка: --repos нельзя сочетать с директорией, --image, --github, --patch и --checkpoint":                                      "error: --repos cannot be combined with a directory, --image, --github, --patch or --checkpoint",
+	"ошибка: --github нельзя сочетать с директорией, --image, --patch, --tracked и --checkpoint":                                    "error: --github cannot be combined with a directory, --image, --patch, --tracked or --checkpoint",
+	"ошибка: --resume требует указать файл контрольной точки через --checkpoint":                                                    "error: --resume requires a checkpoint file set with --checkpoint",
+	"неизвестное значение --color %q (ожидается auto, always или never)":                                                            "unknown --color value %q (expected auto, always or never)",
+	"неизвестный стиль таблицы %q (ожидается plain, box или compact)":                                                               "unknown table style %q (expected plain, box or compact)",
+	"--format без пути можно указать только один раз, остальные форматы записываются в файлы (например, --format json:report.json)": "--format without a path can be given only once; other formats are written to files (for example, --format json:report.json)",
+	"--template задаёт формат вывода сам и не сочетается с --format без пути и --compat":                                            "--template sets the output format itself and cannot be combined with --format without a path or --compat",
+	"--format ndjson не сочетается с другими форматами: файлы не хранятся до конца подсчёта":                                        "--format ndjson cannot be combined with other formats: files are not kept until counting finishes",
+	"--compat tokei выводит JSON и не сочетается с другими форматами":                                                               "--compat tokei prints JSON and cannot be combined with other formats",
+	"неизвестное значение --compat %q (ожидается tokei)":                                                                            "unknown --compat value %q (expected tokei)",
+	"--format sqlite требует указать файл базы: --format sqlite:stats.db или --out stats.db":                                        "--format sqlite requires a database file: --format sqlite:stats.db or --out stats.db",
+	"--format ndjson нельзя сочетать с --repos, --manifest и --chart-out: файлы не хранятся до конца подсчёта":                      "--format ndjson cannot be combined with --repos, --manifest or --chart-out: files are not kept until counting finishes",
+	"--format %s требует указать лимит через --max-lines":                                                                           "--format %s requires a limit set with --max-lines",
+	"неизвестный формат %q (ожидается table, markdown, html, json, ndjson, csv, tsv, xml, sqlite, quickfix или junit)":              "unknown format %q (expected table, markdown, html, json, ndjson, csv, tsv, xml, sqlite, quickfix or junit)",
+	"не найдена утилита sqlite3 (установите её или выберите другой формат)":                                                         "sqlite3 not found (install it or choose another format)",
+	"ошибка: --duplication-min должен быть не меньше 2":                                                                             "error: --duplication-min must be at least 2",
+	"неизвестный синтаксис ассемблера --asm-syntax %q (ожидается auto, gas, nasm, arm или aarch64)":                                 "unknown --asm-syntax value %q (expected auto, gas, nasm, arm or aarch64)",
+	"неизвестный способ определения языка --detect %q (ожидается extension или content)":                                            "unknown --detect value %q (expected extension or content)",
+	"неизвестный способ подсчёта --backend %q (ожидается heuristic или exact)":                                                      "unknown --backend value %q (expected heuristic or exact)",
+	"неизвестный язык --lang %q (ожидается en или ru)":                                                                              "unknown --lang value %q (expected en or ru)",
+
+	// Подкоманды, патчи и предупреждения
+	"Использование: ": "Usage: ",
+	"Добавлено":       "Added",
+	"Удалено":         "Removed",
+	"некорректный заголовок хунка: %q": "malformed hunk header: %q",
+	"ожидался %q":                "expected %q",
+	"ошибка разбора патча: %v\n": "error parsing patch: %v\n",
+	"В патче нет изменений в поддерживаемых исходных файлах.":          "The patch has no changes in supported source files.",
+	"прерывание: обход останавливается, будет выведен частичный отчёт": "interrupted: stopping the walk, a partial report will be printed",
+	"%s: не удалось определить язык по имени файла %q":                 "%s: could not detect the language from file name %q",
+	"гист %s: %w": "gist %s: %w",
+	"предупреждение: --by-owner: файл CODEOWNERS не найден, все файлы без владельца": "warning: --by-owner: no CODEOWNERS file found, all files are unowned",
+	"предупреждение: ошибка записи контрольной точки: %v\n":                          "warning: failed to write checkpoint: %v\n",
+	"предупреждение: %s: %v\n": "warning: %s: %v\n",
+	"ошибка: %s: %v\n":         "error: %s: %v\n",
+	"< 3 мес":                  "< 3 mo",
+	"3–12 мес":                 "3–12 mo",
+	"1–3 года":                 "1–3 yr",
+	"старше":                   "older",
+	"ошибка: history поддерживает только режим --tags": "error: history supports only --tags mode",
+	"Теги, подходящие под шаблон %q, не найдены.\n":    "No tags match pattern %q.\n",
+	"Тег":       "Tag",
+	"Дата":      "Date",
+	"Изменение": "Change",
+	"Строки кода по релизам": "Lines of code by release",
+	"ошибка: ожидается хост/организация (например, github.com/myorg), получено %q\n": "error: expected host/organization (for example, github.com/myorg), got %q\n",
+	"В %s нет репозиториев.\n":                                                     "%s has no repositories.\n",
+	"%s: репозиториев %d, файлов %d, строк кода %d\n":                              "%s: %d repositories, %d files, %d lines of code\n",
+	"ошибка: неизвестный хук %q (ожидается pre-commit или pre-push)\n":             "error: unknown hook %q (expected pre-commit or pre-push)\n",
+	"ошибка: хук %s уже существует (используйте --force для перезаписи)\n":         "error: hook %s already exists (use --force to overwrite)\n",
+	"Хук %s установлен: %s\n":                                                      "Installed %s hook: %s\n",
+	"Текущая версия: %s, последний релиз: %s\n":                                    "Current version: %s, latest release: %s\n",
+	"Обновление не требуется.":                                                     "Already up to date.",
+	"Утилита обновлена до %s.\n":                                                   "Updated to %s.\n",
+	"в релизе %s нет сборки для %s/%s":                                             "release %s has no build for %s/%s",
+	"в релизе %s нет %s: обновление без проверки контрольной суммы не выполняется": "release %s has no %s: refusing to update without checksum verification",
+	"%s: контрольная сумма не совпадает (ожидалась %s, получена %s)":               "%s: checksum mismatch (expected %s, got %s)",
+	"%s: нет контрольной суммы для %s":                                             "%s: no checksum for %s",
+	"в архиве нет %s":                                                              "%s not found in archive",
+	"ошибка: %s: язык не поддерживается (расширение %q)\n":                         "error: %s: unsupported language (extension %q)\n",
+	"Код: %d, комментарии: %d, пустые: %d":                                         "Code: %d, comments: %d, blank: %d",
+	", импорт: %d":              ", imports: %d",
+	", отброшено фильтрами: %d": ", dropped by filters: %d",
+	"код":                 "code",
+	"импорт":              "import",
+	"не --match":          "not --match",
+	"пусто":               "blank",
+	"комментарий":         "comment",
+	"Код по языкам: %s\n": "Code by language: %s\n",
+	"Патч добавляет %d строк кода — больше лимита --max-added %d\n":                                           "The patch adds %d lines of code, over the --max-added limit of %d\n",
+	"предупреждение: лимит не задан, хук будет только выводить отчёт (например: install-hook %s -- %s 500)\n": "warning: no limit set, the hook will only print a report (for example: install-hook %s -- %s 500)\n",
+	"--forecast: некорректная дата или срок %q (ожидается ГГГГ-ММ-ДД или 90d, 8w, 6m, 2y)":                    "--forecast: invalid date or period %q (expected YYYY-MM-DD or 90d, 8w, 6m, 2y)",
+	"Для прогноза нужны хотя бы два тега с разными датами.":                                                   "A forecast needs at least two tags with different dates.",
+	"Прогноз по линейному тренду (тегов: %d, %+.0f строк в месяц):\n":                                         "Linear trend forecast (%d tags, %+.0f lines per month):\n",
+	"некорректный разделитель --csv-delimiter %q (ожидается один символ, кроме кавычки и перевода строки)":    "invalid --csv-delimiter %q (expected a single character other than a quote or newline)",
+	"неизвестное значение --csv-quote %q (ожидается minimal или all)":                                         "unknown --csv-quote value %q (expected minimal or all)",
+	"Макс. длина":     "Max length",
+	"Отступы":         "Indent",
+	"Пробелы в конце": "Trailing spaces",
+	"табуляция":       "tabs",
+	"пробелы":         "spaces",
+	"смешанные":       "mixed",
+	"Объявление":      "Declaration",
+	"Вид":             "Kind",
+	"функция":         "function",
+	"метод":           "method",
+	"тип":             "type",
+	"Место":           "Location",
+	"Совпадает с":     "Same as",
+	"--patch не сочетается с --compat":                     "--patch cannot be combined with --compat",
+	"--patch выводит только форматы table и json, а не %q": "--patch only supports the table and json formats, not %q",
+	"Размер файлов в строках кода":                         "File size in lines of code",
+	"Значение":               "Value",
+	"Медиана":                "Median",
+	"95-й перцентиль":        "95th percentile",
+	"Максимум (%s)":          "Maximum (%s)",
+	"Файлов больше %d строк": "Files over %d lines",
+	"Покрытие: доступно только при подсчёте директории.": "Coverage: only available when counting a directory.",
+	"Покрытие: ни один файл отчёта не найден в профиле.": "Coverage: none of the report files were found in the profile.",
+	"Покрыто":  "Covered",
+	"Покрытие": "Coverage",
+	"Пакет":    "Package",
+	"некорректная строка профиля %q":                               "invalid profile line %q",
+	"некорректная строка lcov %q":                                  "invalid lcov line %q",
+	"ошибка: неизвестный формат %q (ожидается markdown или man)\n": "error: unknown format %q (expected markdown or man)\n",
+	"некорректный шаблон %q":                                       "invalid pattern %q",
+	"неподдерживаемый формат диаграммы %q (поддерживается .svg)":   "unsupported chart format %q (.svg is supported)",
+	"неизвестный столбец %q (доступны: %s)":                        "unknown column %q (available: %s)",
+	"ожидается «.расширение=Язык,Язык», получено %q":               "expected \".ext=Language,Language\", got %q",
+	"неизвестный язык %q":                                          "unknown language %q",
+	"--github: ожидается владелец/репозиторий[@ref], получено %q":  "--github: expected owner/repo[@ref], got %q",
+	"ожидается глубина — целое число от 1, получено %q":            "expected a depth — an integer from 1, got %q",
+	"ожидается «Язык=вес», получено %q":                            "expected \"Language=weight\", got %q",
+	"некорректный вес %q для языка %q":                             "invalid weight %q for language %q",
+	"открытие контрольной точки: %w":                               "opening checkpoint: %w",
+	"обход директории: %w":                                         "walking directory: %w",
+	"понижение приоритета не поддерживается на этой платформе":     "lowering priority is not supported on this platform",
+	"не указан формат":                                             "format not specified",
+	"не указан путь после %q":                                      "no path after %q",
+	"%s:%d: ожидается «имя = аргументы»":                           "%s:%d: expected \"name = arguments\"",
+	"неизвестный набор %q (доступны: %s)":                          "unknown preset %q (available: %s)",
+	"набор %q: %v": "preset %q: %v",
+	"набор %q: лишние аргументы %q":                         "preset %q: unexpected arguments %q",
+	"Строки кода":                                           "Lines of code",
+	"Файлов: %d, строк кода: %d. Отчёт создан %s.":          "Files: %d, lines of code: %d. Report created %s.",
+	"Отчёт неполный: подсчёт прерван, учтены не все файлы.": "Incomplete report: counting was interrupted, not all files are included.",
+	"Языки":                 "Languages",
+	"Директории":            "Directories",
+	"файлов: %d, строк: %d": "files: %d, lines: %d",
+	"Метки в комментариях":  "Comment markers",
+	"Всего: %s.":            "Total: %s.",
+	"Строка":                "Line",
+	"Метка":                 "Marker",
+	"Текст":                 "Text",
+	"Пропущенные файлы":     "Skipped files",
+	"После строки продолжается: /* — блочный комментарий, \" — многострочный литерал, \"\"\" — строка документации, << — тело heredoc.": "Continues after the line: /* — block comment, \" — multiline string, \"\"\" — docstring, << — heredoc body.",
+	"литерал":        "string",
+	"Только пробелы": "Whitespace only",
+	"Переименовано или перемещено файлов: %d (неизменённые строки не учитываются)\n": "Files renamed or moved: %d (unchanged lines are not counted)\n",
+	"%s записан с другими параметрами подсчёта — запустите подсчёт без --resume":     "%s was written with different counting options; run without --resume",
+	"ошибка: порядок языков: %v\n": "error: language order: %v\n",
+	"ошибка: --args-file: %v\n":    "error: --args-file: %v\n",
+	"ошибка: --args-file %s: лишние аргументы %q (директории задаются в командной строке)\n": "error: --args-file %s: unexpected arguments %q (directories go on the command line)\n",
+	"образ %s: %w": "image %s: %w",
+	"слой %s: %w":  "layer %s: %w",
+	"manifest.json не содержит образов":                                            "manifest.json contains no images",
+	"предупреждение: архив содержит %d образов, учитывается первый\n":              "warning: archive contains %d images, counting the first one\n",
+	"не найден ни manifest.json, ни index.json — это не архив docker save или OCI": "neither manifest.json nor index.json found: not a docker save or OCI archive",
+	"index.json не содержит манифестов":                                            "index.json contains no manifests",
+	"манифест %s: %w":                 "manifest %s: %w",
+	"%s: нет в архиве":                "%s: not in archive",
+	"превышено время ожидания чтения": "read timed out",
+
+	// Справка по флагам и подкомандам
+	"Синтаксис комментариев ассемблера (.s, .S, .asm): gas — #, nasm — ;, arm — @, aarch64 — //; auto — по содержимому файла, иначе по расширению.":                                                                                                                      "Assembler comment syntax (.s, .S, .asm): gas — #, nasm — ;, arm — @, aarch64 — //; auto — by file contents, otherwise by extension.",
+	"Способ подсчёта Go-файлов: heuristic — разбор строк, exact — по токенам go/scanner (комментарии внутри строк и многострочные raw-строки учитываются точно; файлы с ошибками синтаксиса и --cgo считаются эвристикой).":                                              "How Go files are counted: heuristic — line parsing, exact — go/scanner tokens (comments inside strings and multiline raw strings are handled exactly; files with syntax errors and --cgo fall back to the heuristic).",
+	"Показать в таблицах, кроме строк кода, строки комментариев, пустые строки и общее число строк, как cloc.":                                                                                                                                                           "Show comment lines, blank lines and total lines in tables alongside code lines, like cloc.",
+	"Промежуточные итоги Go-кода по ограничениям сборки (//go:build и суффиксы _linux.go, _amd64.go).":                                                                                                                                                                   "Subtotals of Go code by build constraint (//go:build and _linux.go, _amd64.go suffixes).",
+	"Промежуточные итоги по директориям до глубины N от корня: --by-dir — верхний уровень, --by-dir=2 — два уровня.":                                                                                                                                                     "Subtotals by directory down to depth N from the root: --by-dir — top level, --by-dir=2 — two levels.",
+	"Добавить сводку по расширениям: число файлов, строк, доля от итога и среднее число строк на файл.":                                                                                                                                                                  "Add a summary by extension: files, lines, share of the total and average lines per file.",
+	"Добавить сводку по языкам: число файлов, строк, доля от итога и среднее число строк на файл.":                                                                                                                                                                       "Add a summary by language: files, lines, share of the total and average lines per file.",
+	"Промежуточные итоги по модулям (директориям с go.mod, Cargo.toml или package.json).":                                                                                                                                                                                "Subtotals by module (directories with go.mod, Cargo.toml or package.json).",
+	"Промежуточные итоги по владельцам кода из CODEOWNERS (действует последнее совпавшее правило).":                                                                                                                                                                      "Subtotals by code owner from CODEOWNERS (the last matching rule wins).",
+	"Добавить размер содержимого файлов в байтах и символах.":                                                                                                                                                                                                            "Add file content size in bytes and characters.",
+	"Считать код C в преамбулах cgo (комментарий перед import \"C\") строками C, а не комментариями Go.":                                                                                                                                                                 "Count C code in cgo preambles (the comment before import \"C\") as C lines rather than Go comments.",
+	"Добавить диаграмму строк по языкам с долями прямо в терминале.":                                                                                                                                                                                                     "Add a chart of lines by language with shares right in the terminal.",
+	"Сохранить диаграмму строк по языкам в файл SVG (например, --chart-out langs.svg).":                                                                                                                                                                                  "Save a chart of lines by language to an SVG file (e.g. --chart-out langs.svg).",
+	"Файл контрольной точки: в него периодически записываются уже посчитанные файлы.":                                                                                                                                                                                    "Checkpoint file: already counted files are periodically written to it.",
+	"Добавить оценку трудоёмкости, срока и стоимости разработки по базовой модели COCOMO.":                                                                                                                                                                               "Add an effort, schedule and cost estimate based on the basic COCOMO model.",
+	"Тип проекта для --cocomo: organic, semi-detached или embedded.":                                                                                                                                                                                                     "Project type for --cocomo: organic, semi-detached or embedded.",
+	"Множитель накладных расходов к зарплате в --cocomo.":                                                                                                                                                                                                                "Salary overhead multiplier for --cocomo.",
+	"Годовая зарплата разработчика для оценки стоимости в --cocomo.":                                                                                                                                                                                                     "Annual developer salary for the --cocomo cost estimate.",
+	"Цвета в таблице: auto — только в терминале (NO_COLOR отключает), always — всегда, never — никогда.":                                                                                                                                                                 "Table colours: auto — only in a terminal (NO_COLOR disables), always — always, never — never.",
+	"Столбцы таблицы файлов через запятую: path, lang, ext, code, comments, blanks, total, density, bytes, chars, tokens, todos, longest, indent, trailing, duplication, imports, size, modified, author, commit (например, --columns path,code,comments,blanks,total).": "Comma-separated file table columns: path, lang, ext, code, comments, blanks, total, density, bytes, chars, tokens, todos, longest, indent, trailing, duplication, imports, size, modified, author, commit (e.g. --columns path,code,comments,blanks,total).",
+	"Вывести JSON в схеме другой утилиты: tokei — как tokei --output json.":                                                                                                                                                                                              "Print JSON in another tool's schema: tokei — like tokei --output json.",
+	"Учитывать каждую жёсткую ссылку на файл отдельно. По умолчанию файл считается один раз.":                                                                                                                                                                            "Count every hard link to a file separately. By default a file is counted once.",
+	"Считать текстовые файлы нераспознанных типов простым текстом (все непустые строки) в группе Other.":                                                                                                                                                                 "Count text files of unrecognised types as plain text (all non-blank lines) in the Other group.",
+	"Профиль покрытия Go (go test -coverprofile) или файл lcov: добавить покрытые строки кода по файлам и пакетам.":                                                                                                                                                      "Go coverage profile (go test -coverprofile) or lcov file: add covered lines of code by file and package.",
+	"Разделитель полей в --format csv: один символ, например ';' для Excel с европейской локалью или \\t.":                                                                                                                                                               "Field delimiter for --format csv: a single character, e.g. ';' for Excel with a European locale, or \\t.",
+	"Кавычки в --format csv: minimal — только поля с разделителем, кавычками или переводом строки, all — все поля.":                                                                                                                                                      "Quoting for --format csv: minimal — only fields with the delimiter, quotes or newlines, all — every field.",
+	"Найти файлы, побайтно совпадающие с другими (по SHA-256 содержимого), — например, скопированные vendored-файлы.":                                                                                                                                                    "Find files byte-for-byte identical to others (by content SHA-256) — e.g. copied vendored files.",
+	"Как --dedupe, но копии не учитываются в таблице и итогах: считается только первый файл с таким содержимым.":                                                                                                                                                         "Like --dedupe, but copies are left out of the table and totals: only the first file with given contents is counted.",
+	"Добавить плотность комментариев — комментарии / (код + комментарии) — по файлам и в сводках по языкам и расширениям.":                                                                                                                                               "Add comment density — comments / (code + comments) — per file and in the language and extension summaries.",
+	"Определение языка: extension — по расширению, имени файла и строке #!, content — ещё и по modeline Emacs/Vim и ключевым словам для файлов неизвестного типа и неоднозначных расширений.":                                                                            "Language detection: extension — by extension, file name and #! line, content — also by Emacs/Vim modelines and keywords for unknown file types and ambiguous extensions.",
+	"Учитывать код во встроенных блоках документации (.md — огороженные блоки ```язык, .rst — code-block, .org — #+BEGIN_SRC) по языкам блоков.":                                                                                                                         "Count code in embedded documentation blocks (.md — ```lang fenced blocks, .rst — code-block, .org — #+BEGIN_SRC) by block language.",
+	"Найти повторяющиеся блоки кода (от --duplication-min строк подряд, без комментариев и отступов) в одном или разных файлах и вывести долю повторов по файлам и в итоге.":                                                                                             "Find repeated blocks of code (at least --duplication-min consecutive lines, ignoring comments and indentation) within and across files and report the duplicated share per file and in total.",
+	"Минимальная длина повторяющегося блока в строках кода для --duplication.":                                                                                                                                                                                           "Minimum length of a repeated block in lines of code for --duplication.",
+	"Директории для исключения (например, --exclude .venv/).":                                                                                                                                                                                                            "Directories to exclude (e.g. --exclude .venv/).",
+	"Шаблоны файлов для исключения (например, --exclude-file '*.min.js'). Шаблон со слешем сравнивается с путём от корня.":                                                                                                                                               "File patterns to exclude (e.g. --exclude-file '*.min.js'). A pattern with a slash is matched against the path from the root.",
+	"Расширения для включения (например, --ext .go --ext .py). По умолчанию: все поддерживаемые.":                                                                                                                                                                        "Extensions to include (e.g. --ext .go --ext .py). Default: all supported.",
+	"Расширения для исключения (например, --ext-exclude .py). Имеет приоритет над --ext.":                                                                                                                                                                                "Extensions to exclude (e.g. --ext-exclude .py). Takes precedence over --ext.",
+	"Завершиться с кодом 3, если превышен лимит --max-lines или (с --patch) --max-added. Для git-хуков и CI.":                                                                                                                                                            "Exit with code 3 if the --max-lines limit or (with --patch) the --max-added limit is exceeded. For git hooks and CI.",
+	"Максимальное время чтения одного файла (например, --file-timeout 30s). По умолчанию: без ограничения.":                                                                                                                                                              "Maximum time to read a single file (e.g. --file-timeout 30s). Default: no limit.",
+	"Формат вывода (по умолчанию table); флаг можно повторять, указывая путь для записи в файл: --format table --format json:report.json. Форматы: table — таблица, markdown — таблицы Markdown для pull request, html — страница с диаграммой и разделами по директориям, json — JSON для других программ, ndjson — строка JSON на файл сразу после подсчёта, csv — CSV для электронных таблиц, tsv — поля через табуляцию для awk/cut, xml — XML для систем сборки, sqlite — запуск в базу SQLite (sqlite:stats.db или --out), quickfix — файлы сверх --max-lines для Vim/Emacs, junit — проверка --max-lines как тесты JUnit для CI.": "Output format (default table); the flag can be repeated, with a path to write to a file: --format table --format json:report.json. Formats: table — a table, markdown — Markdown tables for pull requests, html — a page with a chart and per-directory sections, json — JSON for other programs, ndjson — one JSON line per file right after counting, csv — CSV for spreadsheets, tsv — tab-separated fields for awk/cut, xml — XML for build systems, sqlite — the run into an SQLite database (sqlite:stats.db or --out), quickfix — files over --max-lines for Vim/Emacs, junit — the --max-lines check as JUnit tests for CI.",
+	"Посчитать репозиторий GitHub через API без клонирования (например, --github owner/repo или owner/repo@v1.2).": "Count a GitHub repository through the API without cloning (e.g. --github owner/repo or owner/repo@v1.2).",
+	"Адрес GitHub API (для GitHub Enterprise).": "GitHub API address (for GitHub Enterprise).",
+	"Добавить для Go-файлов строки кода каждой функции, метода и типа (go/ast) и отметить функции длиннее --go-func-limit.":                                  "Add lines of code of every function, method and type in Go files (go/ast) and mark functions longer than --go-func-limit.",
+	"Порог в строках кода, с которого функция или метод считается длинной в --go-detail (0 — не отмечать).":                                                  "Lines of code from which a function or method counts as long in --go-detail (0 — do not mark).",
+	"Не учитывать строки кода, подходящие под регулярное выражение (например, --ignore-lines '^\\s*[{}]\\s*$').":                                             "Do not count lines of code matching the regular expression (e.g. --ignore-lines '^\\s*[{}]\\s*$').",
+	"Посчитать файлы внутри образа контейнера: архив docker save / OCI или ссылка на образ для docker save (например, --image app:latest).":                  "Count files inside a container image: a docker save / OCI archive or an image reference for docker save (e.g. --image app:latest).",
+	"Выделить строки импорта (import, #include, use, using) в отдельную группу, не учитывая их как код.":                                                     "Put import lines (import, #include, use, using) in a separate group instead of counting them as code.",
+	"Учитывать файлы конфигурации (.yaml, .yml, .toml, .ini, .json) отдельной группой: их строки не входят в итоги кода.":                                    "Count configuration files (.yaml, .yml, .toml, .ini, .json) as a separate group: their lines are not part of the code totals.",
+	"Ограничение скорости чтения в МБ/с (например, --io-limit 20). По умолчанию: без ограничения.":                                                           "Read rate limit in MB/s (e.g. --io-limit 20). Default: no limit.",
+	"Язык отчёта и сообщений: en или ru. По умолчанию: по LC_ALL, LC_MESSAGES или LANG.":                                                                     "Report and message language: en or ru. Default: from LC_ALL, LC_MESSAGES or LANG.",
+	"Порядок языков для неоднозначного расширения (например, --lang-priority .h=C++,C). Язык выбирается по признакам в содержимом, иначе — первый в списке.": "Language order for an ambiguous extension (e.g. --lang-priority .h=C++,C). The language is chosen by content hints, otherwise the first in the list.",
+	"Сохранить манифест в JSON: для каждого посчитанного файла — путь, язык, строки, размер и SHA-256 содержимого.":                                          "Save a JSON manifest: path, language, lines, size and content SHA-256 of every counted file.",
+	"Учитывать только строки кода, подходящие под регулярное выражение (например, --match 'log\\.').":                                                        "Count only lines of code matching the regular expression (e.g. --match 'log\\.').",
+	"Лимит добавленных строк кода в патче (--patch).":                                                                                                        "Limit of added lines of code in the patch (--patch).",
+	"Лимит строк кода на файл; файлы сверх лимита отмечаются в отчёте.":                                                                                      "Lines of code limit per file; files over the limit are marked in the report.",
+	"Добавить в таблицу размер файла, время изменения и последний коммит git (автор и дата).":                                                                "Add file size, modification time and the last git commit (author and date) to the table.",
+	"Понизить приоритет процесса по CPU и вводу-выводу, чтобы не мешать другим задачам.":                                                                     "Lower the process CPU and I/O priority so as not to disturb other tasks.",
+	"Не выводить строку заголовков в --format csv и tsv.":                                                                                                    "Do not print the header row in --format csv and tsv.",
+	"Не использовать пейджер ($PAGER) для длинных отчётов в терминале.":                                                                                      "Do not use a pager ($PAGER) for long reports in a terminal.",
+	"Записать в файл вместо stdout отчёт формата, заданного без пути (например, --format html --out report.html).":                                           "Write the report of the format given without a path to a file instead of stdout (e.g. --format html --out report.html).",
+	"Посчитать добавленные и удалённые строки кода в unified diff из файла (\"-\" — из stdin) вместо обхода директории.":                                     "Count added and removed lines of code in a unified diff from a file (\"-\" — from stdin) instead of walking a directory.",
+	"Набор фильтров для типичного запуска: web, backend, infra, docs или свой из файла presets в каталоге настроек. Дополняет остальные флаги.":              "Filter set for a typical run: web, backend, infra, docs or your own from the presets file in the config directory. Adds to the other flags.",
+	"Файл со списком репозиториев (локальные пути или адреса для git clone, по одному на строку): все считаются параллельно и сводятся в один отчёт.":        "File with a list of repositories (local paths or git clone URLs, one per line): all are counted in parallel and combined into one report.",
+	"Продолжить прерванный подсчёт с контрольной точки, заданной --checkpoint.":                                                                              "Resume an interrupted count from the checkpoint given by --checkpoint.",
+	"Добавить распределение размеров файлов: среднее, медиана, 95-й перцентиль, максимум и число больших файлов.":                                            "Add the file size distribution: mean, median, 95th percentile, maximum and number of large files.",
+	"Порог в строках кода, с которого файл считается большим в --stats.":                                                                                     "Lines of code from which a file counts as large in --stats.",
+	"Стиль таблиц: plain — черты из дефисов, box — рамка из символов Unicode, compact — без черт.":                                                           "Table style: plain — dash rules, box — a Unicode frame, compact — no rules.",
+	"Добавить по файлам длину самой длинной строки, стиль отступов (табуляция, пробелы или смешанные) и число строк с пробелами в конце.":                    "Add per file the longest line length, indentation style (tabs, spaces or mixed) and number of lines with trailing spaces.",
+	"Подмодули git: skip — не учитывать, include — учитывать с промежуточными итогами, only — учитывать только их.":                                          "git submodules: skip — ignore, include — count with subtotals, only — count only them.",
+	"Вывести отчёт по шаблону text/template: строка шаблона или путь к файлу с ним (например, --template '{{.Total.Lines}}').":                               "Print the report with a text/template: a template string or a path to a file with it (e.g. --template '{{.Total.Lines}}').",
+	"Дополнительные метки для --todos через запятую (например, --todo-markers XXX,NOTE). Метка ищется целым словом.":                                         "Extra markers for --todos, comma-separated (e.g. --todo-markers XXX,NOTE). A marker is matched as a whole word.",
+	"Посчитать метки TODO, FIXME и HACK в комментариях: по файлам и в итоге.":                                                                                "Count TODO, FIXME and HACK markers in comments: per file and in total.",
+	"Вывести с --todos сами метки в виде «файл:строка: текст».":                                                                                              "With --todos, print the markers themselves as \"file:line: text\".",
+	"Токен GitHub API для --github и гистов. По умолчанию: $GITHUB_TOKEN.":                                                                                   "GitHub API token for --github and gists. Default: $GITHUB_TOKEN.",
+	"Добавить приблизительное число токенов языковой модели (около 4 байт слова на токен, знаки препинания — по токену).":                                    "Add an approximate number of language model tokens (about 4 bytes of a word per token, punctuation — one token each).",
+	"Показать в таблице файлов только N файлов с наибольшим числом строк; итоги считаются по всем файлам.":                                                   "Show only the N files with the most lines in the file table; totals cover all files.",
+	"Учитывать только файлы, известные git (индекс и HEAD).":                                                                                                 "Count only files known to git (index and HEAD).",
+	"Добавить дерево директорий с числом файлов и строк в каждом узле (как du).":                                                                             "Add a directory tree with file and line counts at every node (like du).",
+	"Добавить сводку по нераспознанным расширениям: сколько файлов каждого типа не учтено.":                                                                  "Add a summary of unrecognised extensions: how many files of each type were not counted.",
+	"Веса языков для нормированного итога (например, --weights Python=1.6,Java=1). Язык без веса учитывается с весом 1.":                                     "Language weights for a normalised total (e.g. --weights Python=1.6,Java=1). A language without a weight counts with weight 1.",
+	"Файл с весами языков: по паре «Язык=вес» на строку.":                                                                                                    "File with language weights: one \"Language=weight\" pair per line.",
+	"Директории для исключения (например, --exclude vendor).":                                                                                                "Directories to exclude (e.g. --exclude vendor).",
+	"Шаблоны файлов для исключения (например, --exclude-file '*.pb.go').":                                                                                    "File patterns to exclude (e.g. --exclude-file '*.pb.go').",
+	"Расширения для включения. По умолчанию: все поддерживаемые.":                                                                                            "Extensions to include. Default: all supported.",
+	"Расширения для исключения.":                                                "Extensions to exclude.",
+	"Язык сообщений: en или ru. По умолчанию: по LC_ALL, LC_MESSAGES или LANG.": "Message language: en or ru. Default: from LC_ALL, LC_MESSAGES or LANG.",
+	"loc_counter age [флаги] [директория]":                                      "loc_counter age [flags] [directory]",
+	"Сохранить диаграмму роста по тегам в файл SVG.":                            "Save a chart of growth by tag to an SVG file.",
+	"Спрогнозировать строки кода по языкам на даты через запятую: ГГГГ-ММ-ДД или срок от сегодня (90d, 8w, 6m, 2y).": "Forecast lines of code by language for comma-separated dates: YYYY-MM-DD or a period from today (90d, 8w, 6m, 2y).",
+	"Шаблон имён тегов (например, --pattern 'v*').":                                                       "Tag name pattern (e.g. --pattern 'v*').",
+	"Подсчитать дерево на каждом теге.":                                                                   "Count the tree at every tag.",
+	"loc_counter history --tags [--pattern 'v*'] [--chart-out trend.svg] [--forecast 6m,1y] [директория]": "loc_counter history --tags [--pattern 'v*'] [--chart-out trend.svg] [--forecast 6m,1y] [directory]",
+	"Синтаксис ассемблера: auto, gas, nasm, arm или aarch64.":                                             "Assembler syntax: auto, gas, nasm, arm or aarch64.",
+	"Способ подсчёта Go-файлов: heuristic или exact (go/scanner).":                                        "How Go files are counted: heuristic or exact (go/scanner).",
+	"Считать преамбулы cgo в Go-файлах кодом на C.":                                                       "Count cgo preambles in Go files as C code.",
+	"Определение языка: extension или content (modeline и ключевые слова).":                               "Language detection: extension or content (modelines and keywords).",
+	"Считать блоки кода в документах .md, .rst и .org.":                                                   "Count code blocks in .md, .rst and .org documents.",
+	"Не учитывать строки кода, подходящие под регулярное выражение.":                                      "Do not count lines of code matching the regular expression.",
+	"Выделять строки импорта.":                                                                            "Mark import lines.",
+	"Разбирать файлы конфигурации (YAML, TOML, INI, JSON).":                                               "Parse configuration files (YAML, TOML, INI, JSON).",
+	"Порядок языков для неоднозначного расширения (например, --lang-priority .h=C++,C).":                  "Language order for an ambiguous extension (e.g. --lang-priority .h=C++,C).",
+	"Учитывать только строки кода, подходящие под регулярное выражение.":                                  "Count only lines of code matching the regular expression.",
+	"loc_counter explain [флаги] файл":                                                                    "loc_counter explain [flags] file",
+	"Перезаписать существующий хук.":                                                                      "Overwrite an existing hook.",
+	"Путь к git-репозиторию.":                                                                             "Path to the git repository.",
+	"loc_counter install-hook [флаги] [pre-commit|pre-push] [-- аргументы loc_counter]":                   "loc_counter install-hook [flags] [pre-commit|pre-push] [-- loc_counter arguments]",
+	"Адрес API (для GitHub Enterprise или своего GitLab). По умолчанию определяется по хосту.":            "API address (for GitHub Enterprise or a self-hosted GitLab). Default: derived from the host.",
+	"Не учитывать архивные репозитории.":                                                                  "Skip archived repositories.",
+	"Токен API. По умолчанию: $GITHUB_TOKEN или $GITLAB_TOKEN.":                                           "API token. Default: $GITHUB_TOKEN or $GITLAB_TOKEN.",
+	"loc_counter org github.com/организация|gitlab.com/группа [--token ...]":                              "loc_counter org github.com/organisation|gitlab.com/group [--token ...]",
+	"Адрес GitHub API.": "GitHub API address.",
+	"Только проверить, есть ли новая версия, не обновляя.":                                                     "Only check whether a new version exists, without updating.",
+	"Переустановить, даже если версия совпадает с последней.":                                                  "Reinstall even if the version matches the latest.",
+	"Формат: markdown — справка для README/сайта, man — страница руководства man(1).":                          "Format: markdown — reference for a README/site, man — a man(1) page.",
+	"Записать результат в файл вместо stdout.":                                                                 "Write the result to a file instead of stdout.",
+	"loc_counter gen-docs [--format markdown|man] [--out файл]":                                                "loc_counter gen-docs [--format markdown|man] [--out file]",
+	"loc_counter [флаги] [директория | URL файла | gist:ID]":                                                   "loc_counter [flags] [directory | file URL | gist:ID]",
+	"Не учитывать в --patch строки, изменённые только в пробелах и отступах, и вывести их отдельным столбцом.": "With --patch, leave out lines changed only in whitespace and indentation and show them in a separate column.",
+	"Файл с флагами (через пробел или по строкам, # — комментарий), которые применяются до флагов командной строки — например, общие настройки, закоммиченные в репозиторий.": "File with flags (space- or line-separated, # starts a comment) applied before the command-line flags, e.g. shared settings committed to the repository.",
+}
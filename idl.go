@@ -0,0 +1,32 @@
+package main
+
+import "fmt"
+
+// idlLanguages — языки описания интерфейсов и схем. В сводке по языкам
+// они дополнительно сводятся в группу «IDL/схемы»: объём схем при
+// API-first разработке интересен отдельно от кода.
+var idlLanguages = map[string]bool{
+	"Protocol Buffers": true,
+	"Thrift":           true,
+	"GraphQL":          true,
+}
+
+// idlTotal возвращает итог по файлам на языках описания интерфейсов.
+func (r *report) idlTotal() subtotal {
+	var total subtotal
+	for _, f := range r.files {
+		if idlLanguages[f.lang] {
+			total.add(f.lines)
+		}
+	}
+	return total
+}
+
+// printIDL выводит итог группы «IDL/схемы» под сводкой по языкам.
+func printIDL(rep *report) {
+	total := rep.idlTotal()
+	if total.files == 0 {
+		return
+	}
+	fmt.Printf(tr("IDL/схемы: файлов %d, строк %d (%s от итога)\n\n"), total.files, total.lines, formatShare(total.lines, rep.totalLines))
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Префиксы whiteout-файлов в слоях образа: ими верхний слой удаляет
+// файл нижнего слоя или (.wh..wh..opq) всё содержимое директории.
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+)
+
+// tarEntryKind — итог countTarEntry для записи архива.
+type tarEntryKind int
+
+const (
+	tarIgnored tarEntryKind = iota // не обычный файл, отброшен фильтрами или не прочитан
+	tarUnknown                     // файл нераспознанного типа
+	tarCounted                     // строки файла посчитаны
+)
+
+// imageFile — файл итоговой файловой системы образа.
+type imageFile struct {
+	kind tarEntryKind
+	res  fileResult // только для tarCounted
+}
+
+// scanImage подсчитывает строки кода в файлах, попавших в образ контейнера.
+// ref — путь к архиву docker save / OCI или ссылка на образ, которую можно
+// выгрузить через docker save. Слои накладываются по порядку с учётом
+// whiteout-файлов, так что в отчёт попадает итоговая файловая система образа.
+func scanImage(ref string, opts *options) (*report, error) {
+	archive := ref
+	if _, err := os.Stat(ref); err != nil {
+		tmp, err := saveImage(ref)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(tmp)
+		archive = tmp
+	}
+
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	layers, err := imageLayers(f)
+	if err != nil {
+		return nil, fmt.Errorf(tr("образ %s: %w"), ref, err)
+	}
+
+	lc := opts.lineCounter()
+	rep := opts.newReport()
+	interrupted := watchInterrupt()
+
+	// Файлы итоговой файловой системы: путь без ведущего "/" → результат
+	files := make(map[string]imageFile)
+	for _, layer := range layers {
+		if interrupted.Load() {
+			break
+		}
+		r, err := archiveEntry(f, layer)
+		if err != nil {
+			return nil, fmt.Errorf(tr("слой %s: %w"), layer, err)
+		}
+		if err := applyLayer(r, files, lc, opts, rep, interrupted.Load); err != nil {
+			return nil, fmt.Errorf(tr("слой %s: %w"), layer, err)
+		}
+	}
+	rep.incomplete = interrupted.Load()
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// Копии ищутся среди файлов итоговой файловой системы: файл, удалённый
+	// или перезаписанный верхним слоем, ни с чем не совпадает
+	contents := make(contentIndex)
+	for _, name := range names {
+		f := files[name]
+		if f.kind == tarUnknown {
+			rep.addUnknown(strings.ToLower(path.Ext(name)), opts)
+			continue
+		}
+		if contents.keep(f.res, rep, opts) {
+			rep.add(f.res)
+		}
+	}
+	return rep, nil
+}
+
+// saveImage выгружает образ ref во временный файл через docker save
+// и возвращает путь к нему.
+func saveImage(ref string) (string, error) {
+	tmp, err := os.CreateTemp("", "loc-counter-image-*.tar")
+	if err != nil {
+		return "", err
+	}
+	tmp.Close()
+
+	cmd := exec.Command("docker", "save", "-o", tmp.Name(), ref)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(tmp.Name())
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("docker save %s: %s", ref, msg)
+		}
+		return "", fmt.Errorf("docker save %s: %w", ref, err)
+	}
+	return tmp.Name(), nil
+}
+
+// imageLayers возвращает пути слоёв внутри архива образа от нижнего
+// к верхнему. Поддерживаются manifest.json (docker save) и index.json (OCI).
+func imageLayers(f *os.File) ([]string, error) {
+	if data, err := readArchiveFile(f, "manifest.json"); err == nil {
+		var manifest []struct {
+			Layers []string
+		}
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("manifest.json: %w", err)
+		}
+		if len(manifest) == 0 {
+			return nil, errors.New(tr("manifest.json не содержит образов"))
+		}
+		if len(manifest) > 1 {
+			fmt.Fprintf(os.Stderr, tr("предупреждение: архив содержит %d образов, учитывается первый\n"), len(manifest))
+		}
+		return manifest[0].Layers, nil
+	}
+
+	data, err := readArchiveFile(f, "index.json")
+	if err != nil {
+		return nil, errors.New(tr("не найден ни manifest.json, ни index.json — это не архив docker save или OCI"))
+	}
+	var index struct {
+		Manifests []struct {
+			Digest string
+		}
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("index.json: %w", err)
+	}
+	if len(index.Manifests) == 0 {
+		return nil, errors.New(tr("index.json не содержит манифестов"))
+	}
+	data, err = readArchiveFile(f, blobPath(index.Manifests[0].Digest))
+	if err != nil {
+		return nil, fmt.Errorf(tr("манифест %s: %w"), index.Manifests[0].Digest, err)
+	}
+	var manifest struct {
+		Layers []struct {
+			Digest string
+		}
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf(tr("манифест %s: %w"), index.Manifests[0].Digest, err)
+	}
+	layers := make([]string, 0, len(manifest.Layers))
+	for _, l := range manifest.Layers {
+		layers = append(layers, blobPath(l.Digest))
+	}
+	return layers, nil
+}
+
+// blobPath переводит дайджест OCI ("sha256:...") в путь blobs/sha256/...
+func blobPath(digest string) string {
+	algo, hex, _ := strings.Cut(digest, ":")
+	return path.Join("blobs", algo, hex)
+}
+
+// archiveEntry находит в архиве образа запись name и возвращает её
+// содержимое. Архив читается заново с начала: манифест обычно лежит
+// в конце, а порядок слоёв в архиве не обязан совпадать с манифестом.
+func archiveEntry(f *os.File, name string) (io.Reader, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	name = path.Clean(name)
+	tarReader := tar.NewReader(f)
+	for {
+		hdr, err := tarReader.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf(tr("%s: нет в архиве"), name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if path.Clean(hdr.Name) == name {
+			return tarReader, nil
+		}
+	}
+}
+
+// readArchiveFile читает небольшой файл (манифест) из архива образа.
+func readArchiveFile(f *os.File, name string) ([]byte, error) {
+	r, err := archiveEntry(f, name)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// applyLayer накладывает слой r на files: учитывает whiteout-файлы и
+// подсчитывает строки в поддерживаемых файлах слоя. Любая запись слоя —
+// в том числе директория, символическая ссылка или неподдерживаемый файл —
+// заменяет файл нижнего слоя с тем же путём. Слой может быть сжат gzip.
+func applyLayer(r io.Reader, files map[string]imageFile, lc *lineCounter, opts *options, rep *report, stop func() bool) error {
+	r, err := maybeGunzip(r)
+	if err != nil {
+		return err
+	}
+
+	// Whiteout-файлы относятся к нижним слоям, поэтому применяются
+	// до добавления файлов самого слоя
+	added := make(map[string]imageFile)
+	var removed, opaque []string
+
+	tarReader := tar.NewReader(r)
+	for !stop() {
+		hdr, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		dir, base := path.Split(name)
+		switch {
+		case base == whiteoutOpaque:
+			opaque = append(opaque, dir)
+			continue
+		case strings.HasPrefix(base, whiteoutPrefix):
+			removed = append(removed, dir+strings.TrimPrefix(base, whiteoutPrefix))
+			continue
+		}
+
+		res, kind := countTarEntry(tarReader, hdr, name, "/"+name, lc, opts, rep)
+		added[name] = imageFile{kind, res}
+	}
+
+	for name := range files {
+		for _, dir := range opaque {
+			if strings.HasPrefix(name, dir) {
+				delete(files, name)
+			}
+		}
+		for _, p := range removed {
+			if name == p || strings.HasPrefix(name, p+"/") {
+				delete(files, name)
+			}
+		}
+	}
+	for name, f := range added {
+		if f.kind == tarIgnored {
+			delete(files, name)
+			continue
+		}
+		files[name] = f
+	}
+	return nil
+}
+
+// maybeGunzip распаковывает r, если он сжат gzip, и возвращает его как есть
+// в противном случае.
+func maybeGunzip(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(br)
+	}
+	return br, nil
+}
+
+// countTarEntry подсчитывает строки в записи архива hdr, если она проходит
+// фильтры путей и расширений. name — путь относительно корня архива со
+// слешами, display — путь для отчёта. Ошибка чтения записи попадает
+// в rep.skipped; файл нераспознанного типа вызывающий учитывает сам
+// (rep.addUnknown), когда известно, что запись не удалена позже.
+func countTarEntry(r io.Reader, hdr *tar.Header, name, display string, lc *lineCounter, opts *options, rep *report) (fileResult, tarEntryKind) {
+	if hdr.Typeflag != tar.TypeReg || opts.excludedPath(name) || opts.excludedFile(name) {
+		return fileResult{}, tarIgnored
+	}
+	ext := strings.ToLower(path.Ext(name))
+	r, hasher := opts.manifestReader(r)
+	br := bufio.NewReaderSize(lc.limiter.reader(r), headSize)
+	head := func() []byte {
+		h, _ := br.Peek(headSize)
+		return h
+	}
+	cfg, supported := opts.language(path.Base(name), head)
+	if !supported {
+		return fileResult{}, tarUnknown
+	}
+	if !opts.acceptExt(ext) {
+		return fileResult{}, tarIgnored
+	}
+
+	// Ограничение сборки читается до подсчёта, пока начало файла в буфере
+	var buildTag string
+	if opts.byBuildTag && cfg.Name == "Go" {
+		buildTag = goBuildConstraint(name, head())
+	}
+
+	display = displayPath(display)
+	counts, err := lc.countReader(br, cfg)
+	if err != nil {
+		rep.skipped = append(rep.skipped, skippedFile{display, err.Error()})
+		return fileResult{}, tarIgnored
+	}
+	res := counts.result(display, cfg.Name)
+	if err := hasher.finish(br, &res); err != nil {
+		rep.skipped = append(rep.skipped, skippedFile{display, err.Error()})
+		return fileResult{}, tarIgnored
+	}
+	if opts.meta {
+		res.size = hdr.Size
+		res.modTime = hdr.ModTime
+	}
+	res.buildTag = buildTag
+	return res, tarCounted
+}
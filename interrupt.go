@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+)
+
+// exitInterrupted — код завершения при прерывании подсчёта,
+// как у оболочек при SIGINT (128 + 2).
+const exitInterrupted = 130
+
+// watchInterrupt перехватывает SIGINT/SIGTERM и вместо аварийного завершения
+// выставляет флаг, по которому обход останавливается и выводится частичный
+// отчёт. Повторный сигнал завершает процесс немедленно.
+func watchInterrupt() *atomic.Bool {
+	var stopped atomic.Bool
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ch
+		stopped.Store(true)
+		signal.Stop(ch)
+		fmt.Fprintln(os.Stderr, "\n"+tr("прерывание: обход останавливается, будет выведен частичный отчёт"))
+	}()
+
+	return &stopped
+}
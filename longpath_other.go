@@ -0,0 +1,8 @@
+//go:build !windows
+
+package main
+
+// longPath на системах, отличных от Windows, возвращает путь без изменений.
+func longPath(path string) string {
+	return path
+}
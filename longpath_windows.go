@@ -0,0 +1,27 @@
+//go:build windows
+
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// longPath переводит путь в расширенную форму \\?\, снимающую ограничение
+// MAX_PATH (260 символов). Сетевые пути \\server\share\... переводятся
+// в форму \\?\UNC\server\share\...
+func longPath(path string) string {
+	if strings.HasPrefix(path, `\\?\`) {
+		return path
+	}
+	// Расширенная форма отключает нормализацию пути системой,
+	// поэтому путь обязан быть абсолютным и очищенным — это делает Abs.
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + abs[2:]
+	}
+	return `\\?\` + abs
+}
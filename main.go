@@ -2,151 +2,1165 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // LangConfig описывает синтаксис комментариев для языка.
 type LangConfig struct {
+	Name       string   // название языка для отчётов
 	SingleLine []string // префиксы, обозначающие начало однострочного или inline-комментария
-	MultiStart string   // начало блочного комментария
-	MultiEnd   string   // конец блочного комментария
+	// CommentWords — слова, начинающие однострочный комментарий (REM):
+	// только целым словом и без учёта регистра
+	CommentWords []string
+	MultiStart   string   // начало блочного комментария
+	MultiEnd     string   // конец блочного комментария
+	Nestable     bool     // блочные комментарии вкладываются: /* /* */ */ — один комментарий
+	LineStart    bool     // начало и конец блочного комментария — только в начале строки
+	Strings      []string // ограничители строковых литералов: внутри них нет комментариев
+
+	// MultilineStrings — литералы, которые могут продолжаться на следующих
+	// строках; их строки — код, даже если похожи на комментарий или пусты
+	MultilineStrings []multilineString
+	Imports          []string // префиксы строк импорта/подключения зависимостей
+	Heredocs         []heredocSyntax
+
+	// Docstrings — многострочный литерал первой инструкцией модуля, класса
+	// или функции считается комментарием (строки документации Python)
+	Docstrings bool
+
+	// Fixed — фиксированный формат строк (Fortran 77, COBOL): признак
+	// комментария стоит в определённой колонке, а не в начале текста
+	// строки, а часть колонок может не относиться к тексту программы
+	Fixed *fixedColumns
+
+	// Markup — разметка (HTML, XML): MultilineStrings — значения атрибутов,
+	// они действуют только внутри тегов <…> и могут занимать несколько строк
+	Markup bool
+
+	// Embedded — для документов и файлов из нескольких языков: создаёт
+	// распознаватель блоков. Каждая строка блока считается на языке
+	// своего блока, строки вне блоков — текст документа (комментарий).
+	Embedded func() embedFinder
 }
 
 // knownLanguages сопоставляет расширение файла и конфигурацию языка.
 // Чтобы добавить новый язык, просто добавьте сюда новую запись.
 var knownLanguages = map[string]LangConfig{
 	// C-подобные языки
-	".c":   cStyleConfig(),
-	".h":   cStyleConfig(),
-	".cpp": cStyleConfig(),
-	".cc":  cStyleConfig(),
-	".cxx": cStyleConfig(),
-	".hpp": cStyleConfig(),
+	".c":   cStyleConfig("C", "#include"),
+	".h":   cStyleConfig("C", "#include"),
+	".cpp": cStyleConfig("C++", "#include", "import "),
+	".cc":  cStyleConfig("C++", "#include", "import "),
+	".cxx": cStyleConfig("C++", "#include", "import "),
+	".hpp": cStyleConfig("C++", "#include", "import "),
 	// Java
-	".java": cStyleConfig(),
-	// JavaScript / TypeScript
-	".js":  cStyleConfig(),
-	".ts":  cStyleConfig(),
-	".jsx": cStyleConfig(),
-	".tsx": cStyleConfig(),
+	".java": cStyleConfig("Java", "import "),
+	// JavaScript / TypeScript: шаблонные строки `…` многострочные
+	".js":  cStyleConfig("JavaScript", "import ").withMultilineStrings(templateString),
+	".ts":  cStyleConfig("TypeScript", "import ").withMultilineStrings(templateString),
+	".jsx": cStyleConfig("JavaScript", "import ").withMultilineStrings(templateString),
+	".mjs": cStyleConfig("JavaScript", "import ").withMultilineStrings(templateString),
+	".cjs": cStyleConfig("JavaScript", "import ").withMultilineStrings(templateString),
+	".tsx": cStyleConfig("TypeScript", "import ").withMultilineStrings(templateString),
 	// Go
-	".go": cStyleConfig(),
-	// Rust
-	".rs": cStyleConfig(),
-	// C#
-	".cs": cStyleConfig(),
-	// Python — нет отдельного токена блочного комментария,
-	// используется # и тройные кавычки (обрабатываются как строки)
+	".go": cStyleConfig("Go", "import ", "import(").withMultilineStrings(multilineString{Start: "`", End: "`"}),
+	// Rust: одинарная кавычка — ещё и время жизни ('a), поэтому однострочных
+	// литералов нет, а обычные строки в Rust могут быть многострочными
+	".rs": cStyleConfig("Rust", "use ", "extern crate ").withStrings().withMultilineStrings(rustStrings()...).nestable(),
+	// Scala, Swift и Kotlin: вложенные блочные комментарии, многострочные строки """…"""
+	".scala": scalaConfig,
+	".sc":    scalaConfig,
+	".swift": cStyleConfig("Swift", "import ").withMultilineStrings(multilineString{Start: `"""`, End: `"""`, Escape: true}).nestable(),
+	".kt":    kotlinConfig,
+	".kts":   kotlinConfig,
+	// Dart: вложенные блочные комментарии, строки '''…''' и """…""", raw-строки r'''…'''
+	".dart": cStyleConfig("Dart", "import ", "export ", "part ").withMultilineStrings(
+		multilineString{Start: "r'''", End: "'''"},
+		multilineString{Start: `r"""`, End: `"""`},
+		multilineString{Start: "'''", End: "'''", Escape: true},
+		multilineString{Start: `"""`, End: `"""`, Escape: true},
+	).nestable(),
+	// Objective-C и Objective-C++
+	".m":  cStyleConfig("Objective-C", "#import", "#include", "@import "),
+	".mm": cStyleConfig("Objective-C++", "#import", "#include", "@import "),
+	// Haskell: апостроф бывает частью имени (x'), поэтому строки — только "…"
+	".hs": {
+		Name:       "Haskell",
+		SingleLine: []string{"--"},
+		MultiStart: "{-",
+		MultiEnd:   "-}",
+		Nestable:   true,
+		Strings:    []string{`"`},
+		Imports:    []string{"import "},
+	},
+	// Lua: блочный комментарий --[[ … ]] проверяется раньше однострочного --,
+	// поэтому ---[[ — однострочный комментарий, как и в самом Lua
+	".lua": {
+		Name:             "Lua",
+		SingleLine:       []string{"--"},
+		MultiStart:       "--[[",
+		MultiEnd:         "]]",
+		Strings:          []string{`"`, `'`},
+		MultilineStrings: luaStrings(),
+	},
+	// SQL: строки в одинарных кавычках, в двойных — идентификаторы
+	".sql": {
+		Name:       "SQL",
+		SingleLine: []string{"--"},
+		MultiStart: "/*",
+		MultiEnd:   "*/",
+		Strings:    []string{`'`, `"`},
+	},
+	// Fortran: в фиксированном формате (.f, .for, .f77) строка с C, c, *
+	// или ! в первой колонке — комментарий; в свободном — только !
+	".f":   fortranFixedConfig,
+	".for": fortranFixedConfig,
+	".f77": fortranFixedConfig,
+	".f90": fortranConfig,
+	".f95": fortranConfig,
+	".f03": fortranConfig,
+	".f08": fortranConfig,
+	// COBOL: колонки 1–6 — номера строк, * или / в колонке 7 — комментарий,
+	// колонки после 72 — область идентификации
+	".cob": cobolConfig,
+	".cbl": cobolConfig,
+	".cpy": cobolConfig,
+	// Visual Basic: комментарий — апостроф или REM
+	".vb":  vbConfig,
+	".vbs": vbConfig,
+	".bas": vbConfig,
+	// PowerShell: блочные комментарии <# #>, here-строки @"…"@ и @'…'@
+	".ps1":  powershellConfig,
+	".psm1": powershellConfig,
+	".psd1": powershellConfig,
+	// Пакетные файлы Windows: REM (и @REM) или :: в начале команды
+	".bat": batchConfig,
+	".cmd": batchConfig,
+	// C#: verbatim-строки @"…" многострочные, кавычка в них удваивается
+	".cs": cStyleConfig("C#", "using ").withMultilineStrings(
+		multilineString{Start: `@"`, End: `"`, Doubled: true},
+		multilineString{Start: `$@"`, End: `"`, Doubled: true},
+		multilineString{Start: `@$"`, End: `"`, Doubled: true},
+	),
+	// Python — нет отдельного токена блочного комментария: строки в тройных
+	// кавычках — комментарий только как строки документации (первая
+	// инструкция модуля, класса или функции), в остальных местах — код
 	".py": {
-		SingleLine: []string{"#"},
-		MultiStart: `"""`,
-		MultiEnd:   `"""`,
+		Name:             "Python",
+		SingleLine:       []string{"#"},
+		Strings:          []string{`"`, `'`},
+		MultilineStrings: pythonStrings(),
+		Imports:          []string{"import ", "from "},
+		Docstrings:       true,
 	},
+	// Shell, Ruby, Perl и PHP: тела heredoc (<<EOF … EOF) — код.
+	// Блоки =begin … =end в Ruby и POD в Perl начинаются только с начала строки
+	".sh":      shellConfig,
+	".bash":    shellConfig,
+	".zsh":     shellConfig,
+	".ksh":     shellConfig,
+	".rb":      rubyConfig,
+	".rake":    rubyConfig,
+	".gemspec": rubyConfig,
+	// Perl: из POD учитывается только блок =pod … =cut
+	".pl": perlConfig,
+	".pm": perlConfig,
+	".php": {
+		Name:       "PHP",
+		SingleLine: []string{"//", "#"},
+		MultiStart: "/*",
+		MultiEnd:   "*/",
+		Strings:    []string{`"`, `'`},
+		Imports:    []string{"use ", "require", "include"},
+		Heredocs:   []heredocSyntax{{Start: "<<<", Spaces: true}},
+	},
+	// Разметка с блоками <script> и <style> и стили
+	".html":   markupConfig("HTML"),
+	".htm":    markupConfig("HTML"),
+	".vue":    markupConfig("Vue"),
+	".svelte": markupConfig("Svelte"),
+	".xml":    xmlConfig("XML"),
+	".xsd":    xmlConfig("XML"),
+	".xsl":    xmlConfig("XML"),
+	".xhtml":  xmlConfig("XML"),
+	".svg":    xmlConfig("SVG"),
+	".css":    {Name: "CSS", MultiStart: "/*", MultiEnd: "*/", Strings: []string{`"`, `'`}, Imports: []string{"@import "}},
+	".scss":   cStyleConfig("SCSS", "@import ", "@use ", "@forward "),
+	".less":   cStyleConfig("Less", "@import "),
+	// Ассемблер: диалект выбирает --asm-syntax (asm.go)
+	".s":   asmDialects["gas"],
+	".asm": asmDialects["nasm"],
+	// LaTeX: \% — знак процента, а не комментарий, поэтому \% и \\
+	// (перевод строки перед %) пропускаются как литералы без конца
+	".tex": texConfig,
+	".sty": texConfig,
+	".cls": texConfig,
+	".bib": {Name: "BibTeX", SingleLine: []string{"%"}, MultilineStrings: []multilineString{{Start: `\%`}, {Start: `\\`}}},
+	// Описание интерфейсов и схемы (группа «IDL/схемы», idl.go)
+	".proto":   cStyleConfig("Protocol Buffers", "import "),
+	".thrift":  {Name: "Thrift", SingleLine: []string{"//", "#"}, MultiStart: "/*", MultiEnd: "*/", Strings: []string{`"`, `'`}, Imports: []string{"include ", "cpp_include "}},
+	".graphql": graphqlConfig,
+	".gql":     graphqlConfig,
+	// Сборка и инфраструктура
+	".mk":    makeConfig,
+	".cmake": cmakeConfig,
+	".bzl":   starlarkConfig,
+	// Terraform, Packer, Nomad: комментарии #, // и /* */, heredoc <<EOF
+	".tf":     hclConfig,
+	".tfvars": hclConfig,
+	".hcl":    hclConfig,
+	".nomad":  hclConfig,
+	".groovy": cStyleConfig("Groovy", "import ").withMultilineStrings(
+		multilineString{Start: `"""`, End: `"""`, Escape: true},
+		multilineString{Start: "'''", End: "'''", Escape: true},
+	),
+	// Шаблонизаторы: учитываются только комментарии самого шаблона,
+	// разметка вокруг считается кодом
+	".erb":        {Name: "ERB", MultiStart: "<%#", MultiEnd: "%>"},
+	".j2":         jinjaConfig,
+	".jinja":      jinjaConfig,
+	".jinja2":     jinjaConfig,
+	".hbs":        handlebarsConfig,
+	".handlebars": handlebarsConfig,
+	".cshtml":     razorConfig,
+	".razor":      razorConfig,
+}
+
+// knownFilenames сопоставляет имя файла без расширения (или с расширением,
+// которое не говорит о языке) и конфигурацию языка. Имя сравнивается
+// точно, с учётом регистра, и проверяется раньше расширения.
+var knownFilenames = map[string]LangConfig{
+	"Makefile":       makeConfig,
+	"makefile":       makeConfig,
+	"GNUmakefile":    makeConfig,
+	"Dockerfile":     dockerfileConfig,
+	"Containerfile":  dockerfileConfig,
+	"CMakeLists.txt": cmakeConfig,
+	"Jenkinsfile":    knownLanguages[".groovy"],
+	"Rakefile":       rubyConfig,
+	"Gemfile":        rubyConfig,
+	"Vagrantfile":    rubyConfig,
+	"Podfile":        rubyConfig,
+	"BUILD":          starlarkConfig,
+	"BUILD.bazel":    starlarkConfig,
+	"WORKSPACE":      starlarkConfig,
+	"MODULE.bazel":   starlarkConfig,
 }
 
-func cStyleConfig() LangConfig {
+// languageByName возвращает язык файла с именем name (без директорий)
+// по knownFilenames или по расширению.
+func languageByName(name string) (LangConfig, bool) {
+	if cfg, ok := knownFilenames[name]; ok {
+		return cfg, true
+	}
+	cfg, ok := knownLanguages[strings.ToLower(path.Ext(name))]
+	return cfg, ok
+}
+
+var (
+	fortranConfig = LangConfig{
+		Name:       "Fortran",
+		SingleLine: []string{"!"},
+		Strings:    []string{`"`, `'`},
+		Imports:    []string{"use ", "USE ", "include ", "INCLUDE "},
+	}
+	fortranFixedConfig = fortranConfig.withFixedColumns(fixedColumns{Indicator: 1, Marks: "Cc*!"})
+	hclConfig          = LangConfig{
+		Name:       "HCL",
+		SingleLine: []string{"#", "//"},
+		MultiStart: "/*",
+		MultiEnd:   "*/",
+		Strings:    []string{`"`},
+		Heredocs:   []heredocSyntax{{Start: "<<"}},
+	}
+	// GraphQL: блочные строки """…""" — описания типов и полей
+	graphqlConfig = LangConfig{
+		Name:             "GraphQL",
+		SingleLine:       []string{"#"},
+		Strings:          []string{`"`},
+		MultilineStrings: []multilineString{{Start: `"""`, End: `"""`, Escape: true}},
+	}
+	vbConfig = LangConfig{
+		Name:         "Visual Basic",
+		SingleLine:   []string{"'"},
+		CommentWords: []string{"REM"},
+		Strings:      []string{`"`},
+		Imports:      []string{"Imports ", "imports "},
+	}
+	powershellConfig = LangConfig{
+		Name:       "PowerShell",
+		SingleLine: []string{"#"},
+		MultiStart: "<#",
+		MultiEnd:   "#>",
+		Strings:    []string{`"`, `'`},
+		MultilineStrings: []multilineString{
+			{Start: `@"`, End: `"@`},
+			{Start: "@'", End: "'@"},
+		},
+		Imports: []string{"Import-Module ", "using module ", "using namespace ", "#Requires "},
+	}
+	batchConfig = LangConfig{
+		Name:         "Batch",
+		SingleLine:   []string{"::"},
+		CommentWords: []string{"REM", "@REM"},
+		Strings:      []string{`"`},
+	}
+	cobolConfig = LangConfig{
+		Name:       "COBOL",
+		SingleLine: []string{"*>"},
+		Strings:    []string{`"`, `'`},
+		Imports:    []string{"COPY ", "copy "},
+	}.withFixedColumns(fixedColumns{Indicator: 7, Marks: "*/", Sequence: 6, TextEnd: 72})
+	texConfig = LangConfig{
+		Name:             "LaTeX",
+		SingleLine:       []string{"%"},
+		MultiStart:       `\begin{comment}`,
+		MultiEnd:         `\end{comment}`,
+		MultilineStrings: []multilineString{{Start: `\%`}, {Start: `\\`}},
+		Imports:          []string{`\usepackage`, `\RequirePackage`, `\input`, `\include`},
+	}
+	scalaConfig = cStyleConfig("Scala", "import ").withMultilineStrings(multilineString{Start: `"""`, End: `"""`}).nestable()
+	// Kotlin: в raw-строках """…""" экранирования нет
+	kotlinConfig = cStyleConfig("Kotlin", "import ").withMultilineStrings(multilineString{Start: `"""`, End: `"""`}).nestable()
+	// Make и Dockerfile: «#» — комментарий и в кавычках, строк нет
+	makeConfig       = LangConfig{Name: "Make", SingleLine: []string{"#"}, Imports: []string{"include ", "-include "}}
+	dockerfileConfig = LangConfig{Name: "Dockerfile", SingleLine: []string{"#"}}
+	// CMake: блочные комментарии #[[ … ]]
+	cmakeConfig = LangConfig{
+		Name:       "CMake",
+		SingleLine: []string{"#"},
+		MultiStart: "#[[",
+		MultiEnd:   "]]",
+		Strings:    []string{`"`},
+		Imports:    []string{"include(", "find_package("},
+	}
+	// Starlark (Bazel) — подмножество Python
+	starlarkConfig = LangConfig{
+		Name:             "Starlark",
+		SingleLine:       []string{"#"},
+		Strings:          []string{`"`, `'`},
+		MultilineStrings: pythonStrings(),
+		Imports:          []string{"load("},
+		Docstrings:       true,
+	}
+	shellConfig = LangConfig{
+		Name:       "Shell",
+		SingleLine: []string{"#"},
+		Strings:    []string{`"`, `'`},
+		Imports:    []string{"source ", ". "},
+		Heredocs:   []heredocSyntax{{Start: "<<", Spaces: true}},
+	}
+	rubyConfig = LangConfig{
+		Name:       "Ruby",
+		SingleLine: []string{"#"},
+		MultiStart: "=begin",
+		MultiEnd:   "=end",
+		LineStart:  true,
+		Strings:    []string{`"`, `'`},
+		Imports:    []string{"require ", "require_relative "},
+		Heredocs:   []heredocSyntax{{Start: "<<"}},
+	}
+	perlConfig = LangConfig{
+		Name:       "Perl",
+		SingleLine: []string{"#"},
+		MultiStart: "=pod",
+		MultiEnd:   "=cut",
+		LineStart:  true,
+		Strings:    []string{`"`, `'`},
+		Imports:    []string{"use ", "require "},
+		Heredocs:   []heredocSyntax{{Start: "<<"}},
+	}
+	jinjaConfig = LangConfig{Name: "Jinja", MultiStart: "{#", MultiEnd: "#}"}
+	// {{! ... }} — короткая форма комментария Handlebars, {{!-- ... --}} — блочная
+	handlebarsConfig = LangConfig{Name: "Handlebars", SingleLine: []string{"{{!"}, MultiStart: "{{!--", MultiEnd: "--}}"}
+	razorConfig      = LangConfig{Name: "Razor", MultiStart: "@*", MultiEnd: "*@", Imports: []string{"@using "}}
+)
+
+func cStyleConfig(name string, imports ...string) LangConfig {
 	return LangConfig{
+		Name:       name,
 		SingleLine: []string{"//"},
 		MultiStart: "/*",
 		MultiEnd:   "*/",
+		Strings:    []string{`"`, `'`},
+		Imports:    imports,
 	}
 }
 
-// countLines подсчитывает логические строки кода в файле:
-//   - Пустые строки пропускаются.
-//   - Строки, полностью находящиеся внутри блочного комментария, пропускаются.
-//   - Строки, содержащие только однострочный комментарий (после Trim), пропускаются.
-//   - Строки, содержащие код И комментарий (inline), учитываются.
-func countLines(path string, cfg LangConfig) (int, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return 0, err
+// withStrings возвращает копию конфигурации с другими ограничителями строк.
+func (c LangConfig) withStrings(delims ...string) LangConfig {
+	c.Strings = delims
+	return c
+}
+
+// nestable возвращает копию конфигурации с вложенными блочными комментариями.
+func (c LangConfig) nestable() LangConfig {
+	c.Nestable = true
+	return c
+}
+
+// withMultilineStrings возвращает копию конфигурации с многострочными литералами.
+func (c LangConfig) withMultilineStrings(literals ...multilineString) LangConfig {
+	c.MultilineStrings = literals
+	return c
+}
+
+// withFixedColumns возвращает копию конфигурации с фиксированным форматом строк.
+func (c LangConfig) withFixedColumns(f fixedColumns) LangConfig {
+	c.Fixed = &f
+	return c
+}
+
+// fixedColumns — фиксированный формат строк, в котором смысл символа
+// зависит от его колонки.
+type fixedColumns struct {
+	Indicator int    // колонка признака комментария, с единицы
+	Marks     string // символы, которые в этой колонке делают строку комментарием
+	Sequence  int    // колонки 1..Sequence — номера строк, не текст программы
+	TextEnd   int    // последняя колонка текста; дальше — идентификация (0 — до конца строки)
+}
+
+// text возвращает line без номеров строк и области идентификации:
+// номера заменяются пробелами, чтобы колонки остались на своих местах.
+// Нулевой формат возвращает line как есть.
+func (f *fixedColumns) text(line string) string {
+	if f == nil {
+		return line
 	}
-	defer f.Close()
+	if f.TextEnd > 0 && len(line) > f.TextEnd {
+		line = line[:f.TextEnd]
+	}
+	if n := min(f.Sequence, len(line)); n > 0 {
+		line = strings.Repeat(" ", n) + line[n:]
+	}
+	return line
+}
 
-	count := 0
-	inBlock := false
-	scanner := bufio.NewScanner(f)
+// isComment сообщает, отмечена ли line как комментарий в колонке признака.
+func (f *fixedColumns) isComment(line string) bool {
+	return len(line) >= f.Indicator && strings.IndexByte(f.Marks, line[f.Indicator-1]) >= 0
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		trimmed := strings.TrimSpace(line)
+// multilineString — строковый литерал, который может продолжаться
+// на следующих строках: raw-строки Go, шаблонные строки JavaScript,
+// тройные кавычки Python, raw-строки Rust, verbatim-строки C#.
+type multilineString struct {
+	Start, End string
+	Escape     bool // обратная косая черта экранирует следующий символ
+	Doubled    bool // удвоенный End внутри литерала — экранированный символ
+}
+
+// templateString — шаблонная строка JavaScript и TypeScript.
+var templateString = multilineString{Start: "`", End: "`", Escape: true}
 
-		if trimmed == "" {
+// rustStrings возвращает строковые литералы Rust: обычные строки
+// и raw-строки r"…", r#"…"#, r##"…"## и т. д.
+func rustStrings() []multilineString {
+	// Символ '"' — не начало строки: пустой End закрывает «литерал» сразу
+	literals := []multilineString{{Start: `'"'`}, {Start: `"`, End: `"`, Escape: true}}
+	for n := 0; n <= 3; n++ {
+		hashes := strings.Repeat("#", n)
+		literals = append(literals, multilineString{Start: "r" + hashes + `"`, End: `"` + hashes})
+	}
+	return literals
+}
+
+// luaStrings возвращает длинные строки Lua: [[…]], [=[…]=], [==[…]==].
+func luaStrings() []multilineString {
+	var literals []multilineString
+	for n := 0; n <= 3; n++ {
+		eq := strings.Repeat("=", n)
+		literals = append(literals, multilineString{Start: "[" + eq + "[", End: "]" + eq + "]"})
+	}
+	return literals
+}
+
+// pythonStrings возвращает строки Python в тройных кавычках, в том числе
+// с префиксами r и u, с которыми пишут строки документации.
+func pythonStrings() []multilineString {
+	var literals []multilineString
+	for _, q := range []string{`"""`, "'''"} {
+		literals = append(literals,
+			multilineString{Start: q, End: q, Escape: true},
+			multilineString{Start: "u" + q, End: q, Escape: true},
+			multilineString{Start: "U" + q, End: q, Escape: true},
+			multilineString{Start: "r" + q, End: q},
+			multilineString{Start: "R" + q, End: q},
+		)
+	}
+	return literals
+}
+
+// skip возвращает позицию после конца литерала в line, начиная с from,
+// и закрыт ли литерал на этой строке.
+func (s *multilineString) skip(line string, from int) (int, bool) {
+	for i := from; i < len(line); i++ {
+		if s.Escape && line[i] == '\\' {
+			i++
 			continue
 		}
-
-		// ---- Обработка блочных комментариев ----
-		if cfg.MultiStart != "" {
-			if inBlock {
-				// Всё ещё внутри блочного комментария — проверяем конец
-				if idx := strings.Index(trimmed, cfg.MultiEnd); idx >= 0 {
-					inBlock = false
-					// есть ли что-то после закрывающего токена на этой же строке?
-					rest := strings.TrimSpace(trimmed[idx+len(cfg.MultiEnd):])
-					if rest != "" && !isEntirelyComment(rest, cfg) {
-						count++
-					}
-				}
-				// В любом случае, эта строка не добавляет кода
+		if strings.HasPrefix(line[i:], s.End) {
+			if s.Doubled && strings.HasPrefix(line[i+len(s.End):], s.End) {
+				i += 2*len(s.End) - 1
 				continue
 			}
+			return i + len(s.End), true
+		}
+	}
+	return len(line), s.End == ""
+}
 
-			// Не в блоке — проверяем, начинается ли блок здесь
-			if startIdx := strings.Index(trimmed, cfg.MultiStart); startIdx >= 0 {
-				// Есть ли код перед началом блока?
-				before := strings.TrimSpace(trimmed[:startIdx])
-				hasCodeBefore := before != "" && !isEntirelyComment(before, cfg)
-
-				// Закрывается ли блок на этой же строке?
-				searchFrom := startIdx + len(cfg.MultiStart)
-				if endIdx := strings.Index(trimmed[searchFrom:], cfg.MultiEnd); endIdx >= 0 {
-					// Однострочный блочный комментарий: /*...*/
-					afterEnd := strings.TrimSpace(trimmed[searchFrom+endIdx+len(cfg.MultiEnd):])
-					hasCodeAfter := afterEnd != "" && !isEntirelyComment(afterEnd, cfg)
-					if hasCodeBefore || hasCodeAfter {
-						count++
-					}
-					// inBlock остаётся false
-					continue
-				}
+// matchMultiline возвращает многострочный литерал, который начинается в s, или nil.
+func matchMultiline(s string, literals []multilineString) *multilineString {
+	for i := range literals {
+		if strings.HasPrefix(s, literals[i].Start) {
+			return &literals[i]
+		}
+	}
+	return nil
+}
 
-				// Блок начинается и НЕ заканчивается на этой строке
-				inBlock = true
-				if hasCodeBefore {
-					count++
+// lineKind — класс строки исходного файла.
+type lineKind int
+
+const (
+	lineBlank   lineKind = iota // пустая строка
+	lineComment                 // строка без кода: только комментарий
+	lineCode                    // строка с кодом (возможно, с inline-комментарием)
+)
+
+// lineClassifier классифицирует строки файла по очереди, перенося состояние
+// блочного комментария с одной строки на следующую:
+//   - Пустые строки — lineBlank.
+//   - Строки, в которых есть только комментарии (однострочные или блочные,
+//     в том числе продолжение блока с прошлой строки), — lineComment.
+//   - Строки, содержащие код, в том числе с inline-комментарием, — lineCode.
+//
+// Строка просматривается слева направо. Строковые литералы (cfg.Strings)
+// пропускаются целиком, поэтому «//» и «/*» внутри них не начинают
+// комментарий: url := "http://x" остаётся строкой кода без комментария.
+// Многострочные литералы (cfg.MultilineStrings) переносят состояние
+// на следующие строки так же, как блочный комментарий.
+type lineClassifier struct {
+	cfg      LangConfig
+	depth    int              // вложенность блочного комментария (0 — вне комментария)
+	inString *multilineString // незакрытый многострочный литерал
+	docLit   bool             // незакрытый литерал — строка документации
+	heredocs []string         // метки heredoc, тела которых ещё не закончились
+	inTag    bool             // внутри тега разметки (LangConfig.Markup)
+
+	// Состояние для строк документации (LangConfig.Docstrings)
+	sawCode   bool // в файле уже был код
+	expectDoc bool // следующая инструкция — первая в теле класса или функции
+	header    bool // внутри заголовка def или class
+	brackets  int  // незакрытые скобки
+
+	// comments — участки последней строки, занятые комментариями
+	comments [][2]int
+}
+
+func (c *lineClassifier) classify(line string) lineKind {
+	cfg := c.cfg
+	c.comments = c.comments[:0]
+	line = cfg.Fixed.text(line)
+	i := 0
+	hasCode := false
+	if len(c.heredocs) > 0 {
+		// Тело heredoc — код, даже пустая строка
+		if isHeredocEnd(line, c.heredocs[0]) {
+			c.heredocs = c.heredocs[1:]
+		}
+		return lineCode
+	}
+	if c.inString != nil {
+		// Продолжение многострочного литерала — код, даже пустая строка
+		var closed bool
+		i, closed = c.inString.skip(line, 0)
+		switch {
+		case !closed && c.docLit:
+			// Строка документации — как блочный комментарий
+			if strings.TrimSpace(line) == "" {
+				return lineBlank
+			}
+			c.comments = append(c.comments, [2]int{0, len(line)})
+			return lineComment
+		case !closed:
+			return lineCode
+		case c.docLit:
+			c.comments = append(c.comments, [2]int{0, i})
+		default:
+			hasCode = true
+		}
+		c.inString, c.docLit = nil, false
+	}
+	if !hasCode && strings.TrimSpace(line) == "" {
+		return lineBlank
+	}
+	if cfg.Fixed != nil && !hasCode && c.depth == 0 && cfg.Fixed.isComment(line) {
+		c.comments = append(c.comments, [2]int{0, len(line)})
+		return lineComment
+	}
+
+	if c.depth > 0 {
+		// Всё ещё внутри блочного комментария — ищем его конец
+		i = c.skipBlock(line, 0)
+		c.comments = append(c.comments, [2]int{0, i})
+		if c.depth > 0 {
+			return lineComment
+		}
+	}
+
+	for i < len(line) {
+		rest := line[i:]
+		switch {
+		case line[i] == ' ' || line[i] == '\t' || line[i] == '\r' || line[i] == '\v' || line[i] == '\f':
+			i++
+		case cfg.MultiStart != "" && strings.HasPrefix(rest, cfg.MultiStart) && (!cfg.LineStart || i == 0):
+			// Блочный комментарий: закрывается на этой строке или продолжается
+			start := i
+			c.depth = 1
+			i = c.skipBlock(line, i+len(cfg.MultiStart))
+			c.comments = append(c.comments, [2]int{start, i})
+		case hasAnyPrefix(rest, cfg.SingleLine) && matchMultiline(rest, cfg.MultilineStrings) == nil,
+			matchWord(line, i, cfg.CommentWords):
+			// Однострочный комментарий занимает остаток строки. Литерал,
+			// который начинается так же (#include в ассемблере), — не комментарий
+			c.comments = append(c.comments, [2]int{i, len(line)})
+			i = len(line)
+		default:
+			s := matchMultiline(rest, cfg.MultilineStrings)
+			if cfg.Markup && !c.inTag {
+				// Кавычки в тексте разметки — обычные символы
+				s = nil
+			}
+			if s != nil && cfg.Docstrings && !hasCode && (c.expectDoc || !c.sawCode) {
+				// Строка документации — комментарий
+				start := i
+				var closed bool
+				if i, closed = s.skip(line, i+len(s.Start)); !closed {
+					c.inString, c.docLit = s, true
 				}
+				c.comments = append(c.comments, [2]int{start, i})
+				c.sawCode, c.expectDoc = true, false
 				continue
 			}
+			if !hasCode && cfg.Docstrings {
+				c.startStatement(rest)
+			}
+			hasCode = true
+			if s != nil {
+				var closed bool
+				if i, closed = s.skip(line, i+len(s.Start)); !closed {
+					c.inString = s
+				}
+			} else if label, n := matchHeredoc(rest, cfg.Heredocs); n > 0 {
+				// Тело начинается со следующей строки; несколько heredoc
+				// на одной строке идут друг за другом
+				c.heredocs = append(c.heredocs, label)
+				i += n
+			} else if d := matchPrefix(rest, cfg.Strings); d != "" {
+				i = skipString(line, i+len(d), d)
+			} else {
+				if cfg.Docstrings {
+					c.countBracket(line[i])
+				}
+				if cfg.Markup {
+					c.markupTag(line, i)
+				}
+				i++
+			}
 		}
+	}
+	if hasCode && cfg.Docstrings {
+		c.endStatement(line)
+	}
+	if hasCode {
+		return lineCode
+	}
+	return lineComment
+}
 
-		// ---- Не в блоке и блок не начинается на этой строке ----
-		// Проверяем, покрывает ли однострочный комментарий всю строку
-		if isEntirelyComment(trimmed, cfg) {
-			continue
+// startStatement отмечает начало кода на строке rest для поиска строк
+// документации: после неё строка документации модуля уже невозможна,
+// а def и class начинают заголовок, за которым может идти строка
+// документации их тела.
+func (c *lineClassifier) startStatement(rest string) {
+	c.sawCode = true
+	if c.brackets > 0 || c.header {
+		// Продолжение заголовка или выражения в скобках
+		return
+	}
+	c.expectDoc = false
+	for _, kw := range []string{"def ", "class ", "async def "} {
+		if strings.HasPrefix(rest, kw) {
+			c.header = true
+		}
+	}
+}
+
+// markupTag отмечает начало и конец тега разметки в позиции i line:
+// «<» перед именем, «/» или «?» открывает тег, «>» закрывает.
+func (c *lineClassifier) markupTag(line string, i int) {
+	switch {
+	case line[i] == '>':
+		c.inTag = false
+	case line[i] == '<' && i+1 < len(line) && (line[i+1] == '/' || line[i+1] == '?' || isWordByte(line[i+1], false)):
+		c.inTag = true
+	}
+}
+
+// countBracket учитывает скобку заголовка, который может занимать
+// несколько строк.
+func (c *lineClassifier) countBracket(b byte) {
+	switch b {
+	case '(', '[', '{':
+		c.brackets++
+	case ')', ']', '}':
+		if c.brackets > 0 {
+			c.brackets--
+		}
+	}
+}
+
+// endStatement завершает строку кода: заголовок def или class, закрытый
+// двоеточием в конце строки, открывает тело, первая инструкция которого
+// может быть строкой документации.
+func (c *lineClassifier) endStatement(line string) {
+	if !c.header || c.brackets > 0 || c.inString != nil {
+		return
+	}
+	c.header = false
+	code := line
+	if n := len(c.comments); n > 0 && c.comments[n-1][1] == len(line) {
+		code = line[:c.comments[n-1][0]]
+	}
+	c.expectDoc = strings.HasSuffix(strings.TrimSpace(code), ":")
+}
+
+// inBlock сообщает, закончилась ли последняя строка внутри блочного комментария.
+func (c *lineClassifier) inBlock() bool {
+	return c.depth > 0
+}
+
+// openState — конструкция, внутри которой закончилась строка и которая
+// продолжается на следующей.
+type openState int
+
+const (
+	openNone      openState = iota
+	openComment             // блочный комментарий
+	openString              // многострочный литерал
+	openDocstring           // строка документации
+	openHeredoc             // тело heredoc
+)
+
+// open сообщает, внутри какой конструкции закончилась последняя строка.
+func (c *lineClassifier) open() openState {
+	switch {
+	case c.depth > 0:
+		return openComment
+	case c.inString != nil && c.docLit:
+		return openDocstring
+	case c.inString != nil:
+		return openString
+	case len(c.heredocs) > 0:
+		return openHeredoc
+	}
+	return openNone
+}
+
+// skipBlock пропускает содержимое блочного комментария в line начиная
+// с from и возвращает позицию после его конца или конец строки, если
+// комментарий продолжается. В языках с вложенными комментариями каждое
+// начало блока увеличивает глубину, и комментарий заканчивается, только
+// когда закрыты все вложенные блоки.
+func (c *lineClassifier) skipBlock(line string, from int) int {
+	cfg := c.cfg
+	i := from
+	for i < len(line) {
+		rest := line[i:]
+		switch {
+		case strings.HasPrefix(rest, cfg.MultiEnd) && (!cfg.LineStart || i == 0):
+			i += len(cfg.MultiEnd)
+			if c.depth--; c.depth == 0 {
+				return i
+			}
+		case cfg.Nestable && strings.HasPrefix(rest, cfg.MultiStart):
+			i += len(cfg.MultiStart)
+			c.depth++
+		default:
+			i++
 		}
+	}
+	return i
+}
 
-		count++
+// commentText возвращает текст комментариев последней классифицированной
+// строки line; участки разделяются пробелом.
+func (c *lineClassifier) commentText(line string) string {
+	if len(c.comments) == 1 {
+		return line[c.comments[0][0]:c.comments[0][1]]
+	}
+	parts := make([]string, 0, len(c.comments))
+	for _, span := range c.comments {
+		parts = append(parts, line[span[0]:span[1]])
 	}
+	return strings.Join(parts, " ")
+}
+
+// skipString возвращает позицию после строкового литерала, который
+// начинается в line[from:] (открывающий ограничитель delim уже пропущен).
+// Обратная косая черта экранирует следующий символ. Незакрытый литерал
+// продолжается до конца строки.
+func skipString(line string, from int, delim string) int {
+	for i := from; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(line[i:], delim) {
+			return i + len(delim)
+		}
+	}
+	return len(line)
+}
 
-	return count, scanner.Err()
+// hasAnyPrefix сообщает, начинается ли s с одного из prefixes.
+func hasAnyPrefix(s string, prefixes []string) bool {
+	return matchPrefix(s, prefixes) != ""
 }
 
-// isEntirelyComment возвращает true, если строка (после Trim)
-// начинается с одного из токенов однострочного комментария.
-func isEntirelyComment(s string, cfg LangConfig) bool {
-	for _, prefix := range cfg.SingleLine {
-		if strings.HasPrefix(s, prefix) {
+// matchWord сообщает, стоит ли в позиции i line одно из words целым
+// словом, без учёта регистра: REM — комментарий, а REMOVE и PREM — нет.
+func matchWord(line string, i int, words []string) bool {
+	if i > 0 && isWordByte(line[i-1], true) {
+		return false
+	}
+	for _, w := range words {
+		end := i + len(w)
+		if end <= len(line) && strings.EqualFold(line[i:end], w) && (end == len(line) || !isWordByte(line[end], true)) {
 			return true
 		}
 	}
 	return false
 }
 
+// matchPrefix возвращает первый из prefixes, с которого начинается s,
+// или пустую строку.
+func matchPrefix(s string, prefixes []string) string {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// importTracker распознаёт строки импорта. Многострочный импорт —
+// блок Go `import ( ... )`, `from x import (...)` в Python, `import { ... }`
+// в JavaScript — продолжается, пока не закроются открытые им скобки.
+type importTracker struct {
+	cfg   LangConfig
+	depth int // незакрытые скобки многострочного импорта
+}
+
+// isImport сообщает, относится ли строка кода к импорту.
+func (t *importTracker) isImport(line string) bool {
+	trimmed := strings.TrimSpace(t.cfg.Fixed.text(line))
+	if t.depth == 0 {
+		found := false
+		for _, prefix := range t.cfg.Imports {
+			if strings.HasPrefix(trimmed, prefix) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	t.depth += strings.Count(trimmed, "(") + strings.Count(trimmed, "{") -
+		strings.Count(trimmed, ")") - strings.Count(trimmed, "}")
+	t.depth = max(t.depth, 0)
+	return true
+}
+
+// fileCounts — результат подсчёта строк одного файла.
+type fileCounts struct {
+	Code    int `json:"code"`              // строки кода (без импортов при --imports)
+	Imports int `json:"imports,omitempty"` // строки импорта (только при --imports)
+
+	Comments int `json:"comments,omitempty"` // строки только с комментарием
+	Blanks   int `json:"blanks,omitempty"`   // пустые строки
+
+	// Bytes, Chars и Tokens — размер содержимого в байтах и символах
+	// и приблизительное число токенов (только с --bytes и --tokens)
+	Bytes  int `json:"bytes,omitempty"`
+	Chars  int `json:"chars,omitempty"`
+	Tokens int `json:"tokens,omitempty"`
+
+	// Longest, Indent и Trailing — самая длинная строка в символах, стиль
+	// отступов и число строк с пробелами в конце (только с --style-stats)
+	Longest  int    `json:"longest,omitempty"`
+	Indent   string `json:"indent,omitempty"`
+	Trailing int    `json:"trailing,omitempty"`
+
+	// LineHashes — хеши строк кода для поиска повторов (только с --duplication)
+	LineHashes []uint64 `json:"line_hashes,omitempty"`
+
+	// GoDecls — функции, методы и типы Go-файла (только с --go-detail)
+	GoDecls []goDecl `json:"go_decls,omitempty"`
+
+	// Todos — метки TODO, FIXME и т. п. в комментариях (только с --todos)
+	Todos []todoItem `json:"todos,omitempty"`
+
+	// Parts — строки кода по языкам встроенных блоков (только для документов
+	// и файлов из нескольких языков: HTML, Vue, Svelte)
+	Parts map[string]int `json:"parts,omitempty"`
+}
+
+// result возвращает результат файла path на языке lang.
+func (c fileCounts) result(path, lang string) fileResult {
+	return fileResult{
+		path:     path,
+		lang:     lang,
+		lines:    c.Code,
+		imports:  c.Imports,
+		comments: c.Comments,
+		blanks:   c.Blanks,
+		bytes:    c.Bytes,
+		chars:    c.Chars,
+		tokens:   c.Tokens,
+		todos:    c.Todos,
+		longest:  c.Longest,
+		indent:   c.Indent,
+		trailing: c.Trailing,
+
+		lineHashes: c.LineHashes,
+		goDecls:    c.GoDecls,
+		parts:      c.Parts,
+	}
+}
+
+// lineCounter — настройки подсчёта, общие для всех файлов.
+// Нулевое значение считает все строки кода без ограничений.
+type lineCounter struct {
+	limiter *rateLimiter   // ограничение скорости чтения (nil — без ограничения)
+	timeout time.Duration  // ограничение времени на файл (0 — без ограничения)
+	match   *regexp.Regexp // учитывать только строки кода, подходящие под шаблон (nil — все)
+	ignore  *regexp.Regexp // не учитывать строки кода, подходящие под шаблон (nil — никакие)
+	imports bool           // выделять строки импорта в отдельную группу
+	cgo     bool           // считать преамбулы cgo в Go-файлах кодом на C
+	text    bool           // считать байты, символы и токены (--bytes, --tokens)
+	todos   *regexp.Regexp // искать метки в комментариях (--todos; nil — не искать)
+	style   bool           // собирать статистику оформления (--style-stats)
+	hashes  bool           // собирать хеши строк кода (--duplication)
+	goDecls bool           // разбирать объявления Go-файлов (--go-detail)
+	exact   bool           // считать Go-файлы по токенам go/scanner (--backend exact)
+
+	// trace получает класс каждой строки (подкоманда explain; nil — не получает)
+	trace func(lineTrace)
+}
+
+// countLines подсчитывает логические строки кода (lineCode) в файле.
+// Ненулевой hasher получает всё содержимое файла, прочитанное заодно
+// с подсчётом, — второй раз файл не читается.
+func (lc *lineCounter) countLines(path string, cfg LangConfig, hasher *contentHasher) (fileCounts, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return fileCounts{}, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if hasher != nil {
+		r = io.TeeReader(f, hasher)
+	}
+	r = lc.limiter.reader(r)
+	counts, err := lc.countReader(r, cfg)
+	if err == nil && hasher != nil {
+		_, err = io.Copy(io.Discard, r)
+	}
+	return counts, err
+}
+
+// countReader подсчитывает логические строки кода в содержимом r —
+// файле на диске, записи архива и т. п.
+func (lc *lineCounter) countReader(r io.Reader, cfg LangConfig) (fileCounts, error) {
+	var text *textCounter
+	if lc.text {
+		text = &textCounter{}
+		r = io.TeeReader(r, text)
+	}
+	var todos *todoCounter
+	if lc.todos != nil {
+		todos = newTodoCounter(lc.todos, cfg)
+		r = io.TeeReader(r, todos)
+	}
+	var style *styleCounter
+	if lc.style {
+		style = newStyleCounter()
+		r = io.TeeReader(r, style)
+	}
+	var hashes *lineHasher
+	if lc.hashes {
+		hashes = newLineHasher(cfg)
+		r = io.TeeReader(r, hashes)
+	}
+	var goSrc *goSource
+	if lc.goDecls && cfg.Name == "Go" {
+		goSrc = &goSource{}
+		r = io.TeeReader(r, goSrc)
+	}
+	counts, err := lc.countContent(r, cfg)
+	goSrc.record(&counts)
+	text.record(&counts)
+	todos.record(&counts)
+	style.record(&counts)
+	hashes.record(&counts)
+	return counts, err
+}
+
+// countContent подсчитывает строки содержимого r по правилам языка cfg.
+func (lc *lineCounter) countContent(r io.Reader, cfg LangConfig) (fileCounts, error) {
+	// Преамбулы cgo выделяет только эвристика
+	if lc.exact && cfg.Name == "Go" && !lc.cgo {
+		src, err := io.ReadAll(r)
+		if err != nil {
+			return fileCounts{}, err
+		}
+		if counts, ok := lc.countGoExact(src); ok {
+			return counts, nil
+		}
+		// Файл не разбирается сканером Go — считаем эвристикой
+		r = bytes.NewReader(src)
+	}
+
+	scanner := bufio.NewScanner(r)
+	if cfg.Embedded != nil {
+		return lc.countEmbedded(scanner, cfg.Embedded())
+	}
+
+	if lc.cgo && cfg.Name == "Go" {
+		return lc.countGo(scanner, cfg)
+	}
+
+	st := newLangState(cfg)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		kind, use := lc.countLine(st, line)
+		lc.noteLine(n, line, st, kind, use)
+	}
+	return st.counts, scanner.Err()
+}
+
+// langState — состояние подсчёта непрерывного фрагмента кода на одном языке:
+// файла целиком или встроенного блока.
+type langState struct {
+	classifier lineClassifier
+	imports    importTracker
+	counts     fileCounts
+	open       openState // состояние в конце последней строки, переданной в trace
+}
+
+func newLangState(cfg LangConfig) *langState {
+	return &langState{classifier: lineClassifier{cfg: cfg}, imports: importTracker{cfg: cfg}}
+}
+
+// lineUse — как строка кода учтена при подсчёте.
+type lineUse int
+
+const (
+	useNone      lineUse = iota // не код: пустая строка или комментарий
+	useCode                     // учтена как код
+	useImport                   // учтена как импорт (--imports)
+	useUnmatched                // не подходит под --match
+	useIgnored                  // подходит под --ignore-lines
+)
+
+// countLine классифицирует очередную строку фрагмента, учитывает её в st
+// и возвращает её класс и то, как она учтена.
+func (lc *lineCounter) countLine(st *langState, line string) (lineKind, lineUse) {
+	kind := st.classifier.classify(line)
+	switch kind {
+	case lineBlank:
+		st.counts.Blanks++
+		return kind, useNone
+	case lineComment:
+		st.counts.Comments++
+		return kind, useNone
+	}
+	if lc.match != nil && !lc.match.MatchString(line) {
+		return kind, useUnmatched
+	}
+	if lc.ignore != nil && lc.ignore.MatchString(line) {
+		return kind, useIgnored
+	}
+	if lc.imports && st.imports.isImport(line) {
+		st.counts.Imports++
+		return kind, useImport
+	}
+	st.counts.Code++
+	return kind, useCode
+}
+
+// errFileTimeout возвращается, если чтение файла не уложилось в --file-timeout.
+var errFileTimeout error = timeoutError{}
+
+// timeoutError — ошибка errFileTimeout. Текст переводится при выводе:
+// переменная создаётся раньше, чем выбран язык сообщений.
+type timeoutError struct{}
+
+func (timeoutError) Error() string {
+	return tr("превышено время ожидания чтения")
+}
+
+// countFile вызывает countLines с ограничением времени на файл.
+// Чтение обычного файла нельзя прервать, поэтому при зависании (например,
+// на недоступном NFS/SMB-ресурсе) горутина остаётся ждать в фоне,
+// а обход продолжается со следующего файла.
+func (lc *lineCounter) countFile(path string, cfg LangConfig, hasher *contentHasher) (fileCounts, error) {
+	if lc.timeout <= 0 {
+		return lc.countLines(path, cfg, hasher)
+	}
+
+	type result struct {
+		counts fileCounts
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		counts, err := lc.countLines(path, cfg, hasher)
+		done <- result{counts, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.counts, r.err
+	case <-time.After(lc.timeout):
+		return fileCounts{}, errFileTimeout
+	}
+}
+
+// fileID однозначно идентифицирует файл в пределах машины: жёсткие ссылки
+// и один и тот же файл, видимый через bind mount, имеют одинаковый fileID.
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
 // --- Вспомогательный тип флага ExtStringSlice (позволяет использовать
 // --ext .go --ext .py  ИЛИ  --ext .go,.py) ---
 
@@ -193,130 +1207,439 @@ func normalizeDir(dir string) string {
 	return dir
 }
 
-func main() {
-	var excludeFlag dirStringSlice
-	var extFlag extStringSlice
-	var extExcludeFlag extStringSlice
+// --- Вспомогательный тип флага PatternStringSlice (позволяет использовать
+// --exclude-file '*.min.js' --exclude-file '*.pb.go'  ИЛИ  '*.min.js,*.pb.go') ---
 
-	flag.Var(&excludeFlag, "exclude", "Директории для исключения (например, --exclude .venv/).")
-	flag.Var(&extFlag, "ext", "Расширения для включения (например, --ext .go --ext .py). По умолчанию: все поддерживаемые.")
-	flag.Var(&extExcludeFlag, "ext-exclude", "Расширения для исключения (например, --ext-exclude .py). Имеет приоритет над --ext.")
-	flag.Parse()
+type patternStringSlice []string
 
-	// Определяем директорию
-	dir := ""
-	if flag.NArg() > 0 {
-		dir = flag.Arg(0)
-	} else {
-		fmt.Print("Введите путь к директории [.]: ")
-		fmt.Scanln(&dir)
-		if strings.TrimSpace(dir) == "" {
-			dir = "."
+func (s *patternStringSlice) String() string { return strings.Join(*s, ",") }
+func (s *patternStringSlice) Set(v string) error {
+	for _, part := range strings.Split(v, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
 		}
+		if _, err := path.Match(part, ""); err != nil {
+			return fmt.Errorf(tr("некорректный шаблон %q"), part)
+		}
+		*s = append(*s, part)
 	}
+	return nil
+}
+
+// displayPath приводит путь к виду для вывода: разделители всегда «/»,
+// независимо от платформы, а неотображаемые символы экранируются.
+func displayPath(path string) string {
+	return escapeName(filepath.ToSlash(path))
+}
 
-	// Формируем набор исключений расширений
-	extExcludeSet := make(map[string]bool)
-	for _, e := range extExcludeFlag {
-		extExcludeSet[e] = true
+// escapeName экранирует в имени файла байты, не образующие корректный UTF-8
+// (такие имена встречаются на старых серверах и в архивах), и управляющие
+// символы: они выводятся как \xNN, чтобы не портить таблицу и терминал.
+func escapeName(s string) string {
+	if utf8.ValidString(s) && !strings.ContainsFunc(s, unicode.IsControl) {
+		return s
 	}
 
-	// Формируем набор включений расширений (nil означает «все поддерживаемые»)
-	var extIncludeSet map[string]bool
-	if len(extFlag) > 0 {
-		extIncludeSet = make(map[string]bool)
-		for _, e := range extFlag {
-			extIncludeSet[e] = true
+	var sb strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&sb, `\x%02x`, s[i])
+		case unicode.IsControl(r) && r < 0x100:
+			fmt.Fprintf(&sb, `\x%02x`, r)
+		case unicode.IsControl(r):
+			fmt.Fprintf(&sb, `\u%04x`, r)
+		default:
+			sb.WriteRune(r)
 		}
+		i += size
 	}
+	return sb.String()
+}
 
-	// Обход директории
-	type fileResult struct {
-		path  string
-		lines int
-	}
+// defineFlags объявляет флаги основной команды в fs. Вынесено из main,
+// чтобы gen-docs строил справку по тем же определениям.
+func defineFlags(fs *flag.FlagSet, opts *options, weightsFile *string) {
+	fs.Var(&opts.exclude, "exclude", "Директории для исключения (например, --exclude .venv/).")
+	fs.String("args-file", "", "Файл с флагами (через пробел или по строкам, # — комментарий), которые применяются до флагов командной строки — например, общие настройки, закоммиченные в репозиторий.")
+	fs.Var(&opts.ext, "ext", "Расширения для включения (например, --ext .go --ext .py). По умолчанию: все поддерживаемые.")
+	fs.Var(&opts.extExclude, "ext-exclude", "Расширения для исключения (например, --ext-exclude .py). Имеет приоритет над --ext.")
+	fs.DurationVar(&opts.fileTimeout, "file-timeout", 0, "Максимальное время чтения одного файла (например, --file-timeout 30s). По умолчанию: без ограничения.")
+	fs.BoolVar(&opts.countHardlinks, "count-hardlinks", false, "Учитывать каждую жёсткую ссылку на файл отдельно. По умолчанию файл считается один раз.")
+	fs.StringVar(&opts.checkpoint, "checkpoint", "", "Файл контрольной точки: в него периодически записываются уже посчитанные файлы.")
+	fs.BoolVar(&opts.resume, "resume", false, "Продолжить прерванный подсчёт с контрольной точки, заданной --checkpoint.")
+	fs.Float64Var(&opts.ioLimit, "io-limit", 0, "Ограничение скорости чтения в МБ/с (например, --io-limit 20). По умолчанию: без ограничения.")
+	fs.BoolVar(&opts.nice, "nice", false, "Понизить приоритет процесса по CPU и вводу-выводу, чтобы не мешать другим задачам.")
+	fs.StringVar(&opts.patch, "patch", "", "Посчитать добавленные и удалённые строки кода в unified diff из файла (\"-\" — из stdin) вместо обхода директории.")
+	fs.BoolVar(&opts.tracked, "tracked", false, "Учитывать только файлы, известные git (индекс и HEAD).")
+	fs.StringVar(&opts.submodules, "submodules", "include", "Подмодули git: skip — не учитывать, include — учитывать с промежуточными итогами, only — учитывать только их.")
+	fs.BoolVar(&opts.byModule, "by-module", false, "Промежуточные итоги по модулям (директориям с go.mod, Cargo.toml или package.json).")
+	fs.BoolVar(&opts.noPager, "no-pager", false, "Не использовать пейджер ($PAGER) для длинных отчётов в терминале.")
+	fs.Var(&opts.formats, "format", "Формат вывода (по умолчанию table); флаг можно повторять, указывая путь для записи в файл: --format table --format json:report.json. Форматы: table — таблица, markdown — таблицы Markdown для pull request, html — страница с диаграммой и разделами по директориям, json — JSON для других программ, ndjson — строка JSON на файл сразу после подсчёта, csv — CSV для электронных таблиц, tsv — поля через табуляцию для awk/cut, xml — XML для систем сборки, sqlite — запуск в базу SQLite (sqlite:stats.db или --out), quickfix — файлы сверх --max-lines для Vim/Emacs, junit — проверка --max-lines как тесты JUnit для CI.")
+	fs.IntVar(&opts.maxLines, "max-lines", 0, "Лимит строк кода на файл; файлы сверх лимита отмечаются в отчёте.")
+	fs.IntVar(&opts.maxAdded, "max-added", 0, "Лимит добавленных строк кода в патче (--patch).")
+	fs.BoolVar(&opts.ignoreSpace, "ignore-whitespace", false, "Не учитывать в --patch строки, изменённые только в пробелах и отступах, и вывести их отдельным столбцом.")
+	fs.BoolVar(&opts.failOver, "fail-over", false, "Завершиться с кодом 3, если превышен лимит --max-lines или (с --patch) --max-added. Для git-хуков и CI.")
+	fs.BoolVar(&opts.cocomo, "cocomo", false, "Добавить оценку трудоёмкости, срока и стоимости разработки по базовой модели COCOMO.")
+	fs.StringVar(&opts.cocomoParams.model, "cocomo-model", "organic", "Тип проекта для --cocomo: organic, semi-detached или embedded.")
+	fs.Float64Var(&opts.cocomoParams.salary, "cocomo-salary", 56286, "Годовая зарплата разработчика для оценки стоимости в --cocomo.")
+	fs.Float64Var(&opts.cocomoParams.overhead, "cocomo-overhead", 2.4, "Множитель накладных расходов к зарплате в --cocomo.")
+	fs.BoolVar(&opts.bytes, "bytes", false, "Добавить размер содержимого файлов в байтах и символах.")
+	fs.BoolVar(&opts.tokens, "tokens", false, "Добавить приблизительное число токенов языковой модели (около 4 байт слова на токен, знаки препинания — по токену).")
+	fs.BoolVar(&opts.todos, "todos", false, "Посчитать метки TODO, FIXME и HACK в комментариях: по файлам и в итоге.")
+	fs.Func("todo-markers", "Дополнительные метки для --todos через запятую (например, --todo-markers XXX,NOTE). Метка ищется целым словом.", func(v string) error {
+		for _, m := range strings.Split(v, ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				opts.todoExtra = append(opts.todoExtra, m)
+			}
+		}
+		return nil
+	})
+	fs.BoolVar(&opts.todosList, "todos-list", false, "Вывести с --todos сами метки в виде «файл:строка: текст».")
+	fs.BoolVar(&opts.styleStats, "style-stats", false, "Добавить по файлам длину самой длинной строки, стиль отступов (табуляция, пробелы или смешанные) и число строк с пробелами в конце.")
+	fs.BoolVar(&opts.dedupe, "dedupe", false, "Найти файлы, побайтно совпадающие с другими (по SHA-256 содержимого), — например, скопированные vendored-файлы.")
+	fs.BoolVar(&opts.dedupeExclude, "dedupe-exclude", false, "Как --dedupe, но копии не учитываются в таблице и итогах: считается только первый файл с таким содержимым.")
+	fs.BoolVar(&opts.duplication, "duplication", false, "Найти повторяющиеся блоки кода (от --duplication-min строк подряд, без комментариев и отступов) в одном или разных файлах и вывести долю повторов по файлам и в итоге.")
+	fs.IntVar(&opts.duplicationMin, "duplication-min", 6, "Минимальная длина повторяющегося блока в строках кода для --duplication.")
+	fs.BoolVar(&opts.goDetail, "go-detail", false, "Добавить для Go-файлов строки кода каждой функции, метода и типа (go/ast) и отметить функции длиннее --go-func-limit.")
+	fs.IntVar(&opts.goFuncLimit, "go-func-limit", 50, "Порог в строках кода, с которого функция или метод считается длинной в --go-detail (0 — не отмечать).")
+	fs.StringVar(&opts.backend, "backend", "heuristic", "Способ подсчёта Go-файлов: heuristic — разбор строк, exact — по токенам go/scanner (комментарии внутри строк и многострочные raw-строки учитываются точно; файлы с ошибками синтаксиса и --cgo считаются эвристикой).")
+	fs.StringVar(&opts.asmSyntax, "asm-syntax", "auto", "Синтаксис комментариев ассемблера (.s, .S, .asm): gas — #, nasm — ;, arm — @, aarch64 — //; auto — по содержимому файла, иначе по расширению.")
+	fs.StringVar(&opts.detect, "detect", "extension", "Определение языка: extension — по расширению, имени файла и строке #!, content — ещё и по modeline Emacs/Vim и ключевым словам для файлов неизвестного типа и неоднозначных расширений.")
+	fs.BoolVar(&opts.stats, "stats", false, "Добавить распределение размеров файлов: среднее, медиана, 95-й перцентиль, максимум и число больших файлов.")
+	fs.IntVar(&opts.statsLarge, "stats-large", 500, "Порог в строках кода, с которого файл считается большим в --stats.")
+	fs.BoolVar(&opts.chart, "chart", false, "Добавить диаграмму строк по языкам с долями прямо в терминале.")
+	fs.StringVar(&opts.chartOut, "chart-out", "", "Сохранить диаграмму строк по языкам в файл SVG (например, --chart-out langs.svg).")
+	fs.BoolVar(&opts.meta, "meta", false, "Добавить в таблицу размер файла, время изменения и последний коммит git (автор и дата).")
+	fs.BoolVar(&opts.byLang, "by-lang", false, "Добавить сводку по языкам: число файлов, строк, доля от итога и среднее число строк на файл.")
+	fs.BoolVar(&opts.breakdown, "breakdown", false, "Показать в таблицах, кроме строк кода, строки комментариев, пустые строки и общее число строк, как cloc.")
+	fs.BoolVar(&opts.density, "density", false, "Добавить плотность комментариев — комментарии / (код + комментарии) — по файлам и в сводках по языкам и расширениям.")
+	fs.BoolVar(&opts.byExt, "by-ext", false, "Добавить сводку по расширениям: число файлов, строк, доля от итога и среднее число строк на файл.")
+	fs.Var(opts.weights, "weights", "Веса языков для нормированного итога (например, --weights Python=1.6,Java=1). Язык без веса учитывается с весом 1.")
+	fs.StringVar(weightsFile, "weights-file", "", "Файл с весами языков: по паре «Язык=вес» на строку.")
+	fs.Func("match", "Учитывать только строки кода, подходящие под регулярное выражение (например, --match 'log\\.').", func(v string) error {
+		re, err := regexp.Compile(v)
+		opts.match = re
+		return err
+	})
+	fs.Func("ignore-lines", "Не учитывать строки кода, подходящие под регулярное выражение (например, --ignore-lines '^\\s*[{}]\\s*$').", func(v string) error {
+		re, err := regexp.Compile(v)
+		opts.ignore = re
+		return err
+	})
+	fs.BoolVar(&opts.imports, "imports", false, "Выделить строки импорта (import, #include, use, using) в отдельную группу, не учитывая их как код.")
+	fs.StringVar(&opts.image, "image", "", "Посчитать файлы внутри образа контейнера: архив docker save / OCI или ссылка на образ для docker save (например, --image app:latest).")
+	fs.StringVar(&opts.github, "github", "", "Посчитать репозиторий GitHub через API без клонирования (например, --github owner/repo или owner/repo@v1.2).")
+	fs.StringVar(&opts.token, "token", "", "Токен GitHub API для --github и гистов. По умолчанию: $GITHUB_TOKEN.")
+	fs.StringVar(&opts.githubAPI, "github-api", defaultGitHubAPI, "Адрес GitHub API (для GitHub Enterprise).")
+	fs.StringVar(&opts.repos, "repos", "", "Файл со списком репозиториев (локальные пути или адреса для git clone, по одному на строку): все считаются параллельно и сводятся в один отчёт.")
+	fs.Var(opts.priority, "lang-priority", "Порядок языков для неоднозначного расширения (например, --lang-priority .h=C++,C). Язык выбирается по признакам в содержимом, иначе — первый в списке.")
+	fs.BoolVar(&opts.unknown, "unknown", false, "Добавить сводку по нераспознанным расширениям: сколько файлов каждого типа не учтено.")
+	fs.BoolVar(&opts.includeConfig, "include-config", false, "Учитывать файлы конфигурации (.yaml, .yml, .toml, .ini, .json) отдельной группой: их строки не входят в итоги кода.")
+	fs.BoolVar(&opts.docCode, "doc-code", false, "Учитывать код во встроенных блоках документации (.md — огороженные блоки ```язык, .rst — code-block, .org — #+BEGIN_SRC) по языкам блоков.")
+	fs.BoolVar(&opts.byBuildTag, "by-build-tag", false, "Промежуточные итоги Go-кода по ограничениям сборки (//go:build и суффиксы _linux.go, _amd64.go).")
+	fs.BoolVar(&opts.cgo, "cgo", false, "Считать код C в преамбулах cgo (комментарий перед import \"C\") строками C, а не комментариями Go.")
+	fs.BoolVar(&opts.byOwner, "by-owner", false, "Промежуточные итоги по владельцам кода из CODEOWNERS (действует последнее совпавшее правило).")
+	fs.Func("coverage", "Профиль покрытия Go (go test -coverprofile) или файл lcov: добавить покрытые строки кода по файлам и пакетам.", func(v string) error {
+		cov, err := loadCoverage(v)
+		opts.coverage = cov
+		return err
+	})
+	fs.BoolVar(&opts.countUnknown, "count-unknown", false, "Считать текстовые файлы нераспознанных типов простым текстом (все непустые строки) в группе Other.")
+	fs.StringVar(&opts.manifest, "manifest", "", "Сохранить манифест в JSON: для каждого посчитанного файла — путь, язык, строки, размер и SHA-256 содержимого.")
+	fs.Var(&opts.excludeFiles, "exclude-file", "Шаблоны файлов для исключения (например, --exclude-file '*.min.js'). Шаблон со слешем сравнивается с путём от корня.")
+	fs.StringVar(&opts.out, "out", "", "Записать в файл вместо stdout отчёт формата, заданного без пути (например, --format html --out report.html).")
+	fs.StringVar(&opts.compat, "compat", "", "Вывести JSON в схеме другой утилиты: tokei — как tokei --output json.")
+	fs.StringVar(&opts.template, "template", "", "Вывести отчёт по шаблону text/template: строка шаблона или путь к файлу с ним (например, --template '{{.Total.Lines}}').")
+	fs.StringVar(&opts.color, "color", "auto", "Цвета в таблице: auto — только в терминале (NO_COLOR отключает), always — всегда, never — никогда.")
+	fs.Var(&opts.columns, "columns", "Столбцы таблицы файлов через запятую: path, lang, ext, code, comments, blanks, total, density, bytes, chars, tokens, todos, longest, indent, trailing, duplication, imports, size, modified, author, commit (например, --columns path,code,comments,blanks,total).")
+	fs.StringVar(&opts.style, "style", "plain", "Стиль таблиц: plain — черты из дефисов, box — рамка из символов Unicode, compact — без черт.")
+	fs.IntVar(&opts.top, "top", 0, "Показать в таблице файлов только N файлов с наибольшим числом строк; итоги считаются по всем файлам.")
+	fs.Var(&opts.byDir, "by-dir", "Промежуточные итоги по директориям до глубины N от корня: --by-dir — верхний уровень, --by-dir=2 — два уровня.")
+	fs.BoolVar(&opts.tree, "tree", false, "Добавить дерево директорий с числом файлов и строк в каждом узле (как du).")
+	fs.StringVar(&opts.lang, "lang", "", "Язык отчёта и сообщений: en или ru. По умолчанию: по LC_ALL, LC_MESSAGES или LANG.")
+	fs.BoolVar(&opts.noHeader, "no-header", false, "Не выводить строку заголовков в --format csv и tsv.")
+	fs.StringVar(&opts.csvDelimiter, "csv-delimiter", ",", "Разделитель полей в --format csv: один символ, например ';' для Excel с европейской локалью или \\t.")
+	fs.StringVar(&opts.csvQuote, "csv-quote", "minimal", "Кавычки в --format csv: minimal — только поля с разделителем, кавычками или переводом строки, all — все поля.")
+	fs.Func("preset", "Набор фильтров для типичного запуска: web, backend, infra, docs или свой из файла presets в каталоге настроек. Дополняет остальные флаги.", func(v string) error {
+		return applyPreset(v, opts)
+	})
+}
+
+func main() {
+	// Язык сообщений по локали окружения; --lang основной команды
+	// и подкоманд уточняет его ещё до разбора флагов, чтобы справка
+	// и ошибки значений флагов выводились на выбранном языке
+	locale = defaultLocale()
+	setLocale(argsLocale(os.Args[1:]))
 
-	var results []fileResult
-	totalLines := 0
-	totalFiles := 0
+	// Подкоманды
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "age":
+			runAge(os.Args[2:])
+			return
+		case "install-hook":
+			runInstallHook(os.Args[2:])
+			return
+		case "history":
+			runHistory(os.Args[2:])
+			return
+		case "org":
+			runOrg(os.Args[2:])
+			return
+		case "gen-docs":
+			runGenDocs(os.Args[2:])
+			return
+		case "self-update":
+			runSelfUpdate(os.Args[2:])
+			return
+		case "explain":
+			runExplain(os.Args[2:])
+			return
+		}
+	}
 
-	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+	opts := options{weights: make(weightTable), priority: make(languagePriority)}
+	var weightsFile string
+	defineFlags(flag.CommandLine, &opts, &weightsFile)
+	flag.Usage = commandUsage(flag.CommandLine, mainSynopsis)
+	// Флаги из --args-file разбираются первыми: заданные в командной строке
+	// применяются поверх них, а списки (--ext, --exclude) дополняют их
+	if name := argsValue(os.Args[1:], "args-file"); name != "" {
+		args, err := readArgsFile(name)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "предупреждение: невозможно получить доступ к %s: %v\n", path, err)
-			return nil
+			fmt.Fprintf(os.Stderr, tr("ошибка: --args-file: %v\n"), err)
+			os.Exit(2)
 		}
+		flag.CommandLine.Parse(args)
+		if flag.NArg() > 0 {
+			fmt.Fprintf(os.Stderr, tr("ошибка: --args-file %s: лишние аргументы %q (директории задаются в командной строке)\n"), name, flag.Args())
+			os.Exit(2)
+		}
+	}
+	flag.Parse()
 
-		if d.IsDir() {
-			// Проверяем, нужно ли пропустить эту директорию
-			if len(excludeFlag) > 0 {
-				dirName := normalizeDir(filepath.Base(path))
-				relPath, relErr := filepath.Rel(dir, path)
+	setLocale(opts.lang)
 
-				for _, excluded := range excludeFlag {
-					excluded = strings.TrimSuffix(excluded, "/")
-					if dirName == normalizeDir(excluded) {
-						return filepath.SkipDir
-					}
+	if err := opts.priority.load(); err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: порядок языков: %v\n"), err)
+		os.Exit(2)
+	}
 
-					if relErr == nil && (relPath == excluded || strings.HasPrefix(relPath+"/", excluded+"/")) {
-						return filepath.SkipDir
-					}
-				}
+	// Веса из файла не перекрывают заданные флагом --weights
+	if weightsFile != "" {
+		fromFile := make(weightTable)
+		if err := fromFile.load(weightsFile); err != nil {
+			fmt.Fprintf(os.Stderr, tr("ошибка: --weights-file: %v\n"), err)
+			os.Exit(2)
+		}
+		for lang, w := range fromFile {
+			if _, ok := opts.weights[lang]; !ok {
+				opts.weights[lang] = w
 			}
-			return nil
 		}
+	}
 
-		ext := strings.ToLower(filepath.Ext(path))
-		cfg, supported := knownLanguages[ext]
-		if !supported {
-			return nil
-		}
+	if opts.submodules != "skip" && opts.submodules != "include" && opts.submodules != "only" {
+		fmt.Fprintf(os.Stderr, tr("ошибка: недопустимое значение --submodules %q (ожидается skip, include или only)\n"), opts.submodules)
+		os.Exit(2)
+	}
 
-		// Применяем фильтры
-		if extExcludeSet[ext] {
-			return nil
-		}
-		if extIncludeSet != nil && !extIncludeSet[ext] {
-			return nil
+	if opts.image != "" && (opts.patch != "" || opts.tracked || opts.checkpoint != "" || flag.NArg() > 0) {
+		fmt.Fprintln(os.Stderr, tr("ошибка: --image нельзя сочетать с директорией, --patch, --tracked и --checkpoint"))
+		os.Exit(2)
+	}
+	if opts.repos != "" && (opts.image != "" || opts.github != "" || opts.patch != "" || opts.checkpoint != "" || flag.NArg() > 0) {
+		fmt.Fprintln(os.Stderr, tr("ошибка: --repos нельзя сочетать с директорией, --image, --github, --patch и --checkpoint"))
+		os.Exit(2)
+	}
+	if opts.github != "" && (opts.image != "" || opts.patch != "" || opts.tracked || opts.checkpoint != "" || flag.NArg() > 0) {
+		fmt.Fprintln(os.Stderr, tr("ошибка: --github нельзя сочетать с директорией, --image, --patch, --tracked и --checkpoint"))
+		os.Exit(2)
+	}
+
+	if opts.resume && opts.checkpoint == "" {
+		fmt.Fprintln(os.Stderr, tr("ошибка: --resume требует указать файл контрольной точки через --checkpoint"))
+		os.Exit(2)
+	}
+
+	if opts.duplicationMin < 2 {
+		fmt.Fprintln(os.Stderr, tr("ошибка: --duplication-min должен быть не меньше 2"))
+		os.Exit(2)
+	}
+
+	// Исключить копии можно, только найдя их
+	if opts.dedupeExclude {
+		opts.dedupe = true
+	}
+
+	if err := checkAsmSyntax(opts.asmSyntax); err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+		os.Exit(2)
+	}
+	if err := checkDetect(opts.detect); err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+		os.Exit(2)
+	}
+	if err := checkBackend(opts.backend); err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+		os.Exit(2)
+	}
+	if err := checkCocomoModel(opts.cocomoParams.model); err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+		os.Exit(2)
+	}
+	if err := checkColorMode(opts.color); err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+		os.Exit(2)
+	}
+	if err := checkCSVDialect(opts.csvDelimiter, opts.csvQuote); err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+		os.Exit(2)
+	}
+	if err := checkTableStyle(opts.style); err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+		os.Exit(2)
+	}
+	tableStyle = opts.style
+	if err := opts.setupOutputs(); err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+		os.Exit(2)
+	}
+
+	if opts.chartOut != "" {
+		if err := checkChartPath(opts.chartOut); err != nil {
+			fmt.Fprintf(os.Stderr, tr("ошибка: --chart-out: %v\n"), err)
+			os.Exit(2)
 		}
+	}
 
-		lines, err := countLines(path, cfg)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "предупреждение: невозможно прочитать %s: %v\n", path, err)
-			return nil
+	if opts.nice {
+		if err := lowerPriority(); err != nil {
+			fmt.Fprintf(os.Stderr, tr("предупреждение: не удалось понизить приоритет: %v\n"), err)
 		}
+	}
 
-		results = append(results, fileResult{path, lines})
-		totalLines += lines
-		totalFiles++
-		return nil
-	})
+	// Режим патча: считаем изменения из unified diff вместо обхода директории
+	if opts.patch != "" {
+		runPatch(&opts)
+		return
+	}
+
+	var rep *report
+	var err error
 
+	// Файлы отчётов создаются до подсчёта: потоковый вывод пишет в них сразу,
+	// а ошибка в пути обнаруживается до долгого обхода. База SQLite
+	// дописывается, а не перезаписывается
+	for i := range opts.formats {
+		t := &opts.formats[i]
+		if t.path != "" && t.format != "sqlite" {
+			if t.file, err = os.Create(t.path); err != nil {
+				fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+				os.Exit(1)
+			}
+		}
+		if t.format == "ndjson" {
+			var w io.Writer = os.Stdout
+			if t.file != nil {
+				w = t.file
+			}
+			opts.stream = ndjsonStream(w)
+		}
+	}
+
+	switch {
+	case opts.image != "":
+		// Режим образа: считаем файлы из слоёв контейнера вместо обхода директории
+		rep, err = scanImage(opts.image, &opts)
+	case opts.repos != "":
+		// Несколько репозиториев: параллельный подсчёт и общий отчёт
+		var repos []string
+		if repos, err = readRepoList(opts.repos); err == nil {
+			rep = scanRepos(repos, func(repo string) (*report, error) {
+				return scanRepo(repo, &opts)
+			})
+		}
+	case opts.github != "":
+		// Удалённый репозиторий: архив дерева через GitHub API, без git и клона
+		rep, err = scanGitHub(newGitHubClient(opts.githubAPI, opts.token), opts.github, &opts)
+	case flag.NArg() > 0 && isRemoteTarget(flag.Arg(0)):
+		// Один удалённый файл по ссылке или гист
+		rep, err = scanRemote(newGitHubClient(opts.githubAPI, opts.token), flag.Arg(0), &opts)
+	default:
+		// Определяем директорию
+		dir := ""
+		if flag.NArg() > 0 {
+			dir = flag.Arg(0)
+		} else {
+			fmt.Print(tr("Введите путь к директории [.]: "))
+			fmt.Scanln(&dir)
+			if strings.TrimSpace(dir) == "" {
+				dir = "."
+			}
+		}
+		rep, err = scan(dir, &opts)
+	}
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "ошибка обхода директории: %v\n", err)
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
 		os.Exit(1)
 	}
 
-	if totalFiles == 0 {
-		fmt.Println("Поддерживаемые исходные файлы не найдены.")
-		return
+	if opts.duplication {
+		rep.findDuplication(opts.duplicationMin)
 	}
 
-	// Вывод результатов по каждому файлу
-	maxPathLen := 0
-	for _, r := range results {
-		if len(r.path) > maxPathLen {
-			maxPathLen = len(r.path)
+	if opts.manifest != "" {
+		if err := writeManifest(opts.manifest, rep); err != nil {
+			fmt.Fprintf(os.Stderr, tr("ошибка сохранения манифеста: %v\n"), err)
+			os.Exit(1)
 		}
 	}
 
-	fmt.Println()
-	fmt.Printf("%-*s  %s\n", maxPathLen, "Файл", "Строки")
-	fmt.Println(strings.Repeat("-", maxPathLen+10))
-	for _, r := range results {
-		fmt.Printf("%-*s  %d\n", maxPathLen, r.path, r.lines)
+	if opts.chartOut != "" {
+		if err := writeChart(opts.chartOut, tr("Строки кода по языкам"), rep.languageBars()); err != nil {
+			fmt.Fprintf(os.Stderr, tr("ошибка сохранения диаграммы: %v\n"), err)
+			os.Exit(1)
+		}
+	}
+
+	var console *formatTarget
+	for i := range opts.formats {
+		t := &opts.formats[i]
+		switch {
+		case t.file != nil:
+			if err := writeOutput(t.file, func() { t.output(rep, &opts) }); err != nil {
+				fmt.Fprintf(os.Stderr, tr("ошибка сохранения отчёта %s: %v\n"), t.path, err)
+				os.Exit(1)
+			}
+		case t.path != "":
+			// База SQLite: вывод сам пишет в файл
+			t.output(rep, &opts)
+		default:
+			console = t
+		}
+	}
+	if console != nil {
+		// Цвета определяются до пейджера: он подменяет stdout
+		colorOutput = opts.colorEnabled()
+		// Длинный отчёт в терминале показывается через пейджер
+		var pg *pager
+		if !opts.noPager {
+			pg = startPager()
+		}
+		console.output(rep, &opts)
+		pg.finish()
+	}
+	rep.printSkipped()
+
+	if rep.incomplete {
+		os.Exit(exitInterrupted)
+	}
+	if opts.failOver && len(rep.overBudget(opts.maxLines)) > 0 {
+		os.Exit(exitOverLimit)
 	}
-	fmt.Println(strings.Repeat("-", maxPathLen+10))
-	fmt.Printf("%-*s  %d\n", maxPathLen, fmt.Sprintf("Итого (%d файлов)", totalFiles), totalLines)
-	fmt.Println()
 }
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	const (
+		B = lineBlank
+		C = lineComment
+		K = lineCode
+	)
+	tests := []struct {
+		name string
+		ext  string
+		src  string
+		want []lineKind
+	}{
+		{
+			name: "блочный комментарий на нескольких строках",
+			ext:  ".c",
+			src:  "/* начало\n   продолжение */\nint x;\n",
+			want: []lineKind{C, C, K},
+		},
+		{
+			name: "код после закрытия блока",
+			ext:  ".c",
+			src:  "/* a\n */ int x;\n",
+			want: []lineKind{C, K},
+		},
+		{
+			name: "вложенный блок в C закрывается первым */",
+			ext:  ".c",
+			src:  "/* a /* b */\nint x; */\n",
+			want: []lineKind{C, K},
+		},
+		{
+			name: "вложенный блок в Rust",
+			ext:  ".rs",
+			src:  "/* a /* b */\nfn f() {} */\nlet x = 1;\n",
+			want: []lineKind{C, C, K},
+		},
+		{
+			name: "незакрытый блочный комментарий до конца файла",
+			ext:  ".c",
+			src:  "int x;\n/* без конца\nint y;\n\nint z;\n",
+			want: []lineKind{K, C, C, B, C},
+		},
+		{
+			name: "маркеры комментариев внутри строк",
+			ext:  ".go",
+			src:  "url := \"http://x\"\ns := \"/* не блок\"\nr := `// и это`\n// комментарий\n",
+			want: []lineKind{K, K, K, C},
+		},
+		{
+			name: "экранированная кавычка внутри строки",
+			ext:  ".c",
+			src:  "char *s = \"\\\" /*\";\nint x;\n",
+			want: []lineKind{K, K},
+		},
+		{
+			name: "строка документации Python — комментарий",
+			ext:  ".py",
+			src:  "\"\"\"Модуль.\n\nПодробности.\n\"\"\"\ndef f():\n    \"\"\"Функция.\"\"\"\n    return 1\n",
+			want: []lineKind{C, B, C, C, K, C, K},
+		},
+		{
+			name: "присвоенная строка в тройных кавычках — код",
+			ext:  ".py",
+			src:  "import os\nx = \"\"\"\n# не комментарий\n\"\"\"\n",
+			want: []lineKind{K, K, K, K},
+		},
+		{
+			name: "тело heredoc в shell — код",
+			ext:  ".sh",
+			src:  "cat <<EOF\n# не комментарий\n\nEOF\n# комментарий\n",
+			want: []lineKind{K, K, K, K, C},
+		},
+		{
+			name: "heredoc с отступом в shell",
+			ext:  ".sh",
+			src:  "cat <<-END\n\t# текст\n\tEND\necho ok\n",
+			want: []lineKind{K, K, K, K},
+		},
+		{
+			name: "Fortran: комментарий в первой колонке",
+			ext:  ".f",
+			src:  "C     комментарий\n*     тоже\n      X = 1\n      ! и это\n",
+			want: []lineKind{C, C, K, C},
+		},
+		{
+			name: "COBOL: индикатор в седьмой колонке, текст после 72-й не учитывается",
+			ext:  ".cob",
+			src:  "000100* комментарий\n000200 MOVE 1 TO X.\n000300/\n000400" + strings.Repeat(" ", 66) + "PROGID01\n",
+			want: []lineKind{C, K, C, B},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := lineClassifier{cfg: knownLanguages[tt.ext]}
+			lines := strings.Split(strings.TrimSuffix(tt.src, "\n"), "\n")
+			if len(lines) != len(tt.want) {
+				t.Fatalf("строк %d, ожидаемых классов %d", len(lines), len(tt.want))
+			}
+			for i, line := range lines {
+				if got := c.classify(line); got != tt.want[i] {
+					t.Errorf("строка %d %q: получено %d, ожидалось %d", i+1, line, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCountPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   string
+		want    map[string]patchCounts
+		renamed int
+	}{
+		{
+			name:  "заголовок хунка без числа строк",
+			patch: "--- a/a.go\n+++ b/a.go\n@@ -3 +3 @@\n-var x = 1\n+var x = 2\n",
+			want:  map[string]patchCounts{"Go": {added: 1, removed: 1}},
+		},
+		{
+			name:  "новый файл из /dev/null: комментарии и пустые строки не учитываются",
+			patch: "--- /dev/null\n+++ b/a.go\n@@ -0,0 +1,3 @@\n+package a\n+\n+// комментарий\n",
+			want:  map[string]patchCounts{"Go": {added: 1}},
+		},
+		{
+			name:  "удалённый файл: язык по старому пути",
+			patch: "--- a/old.py\n+++ /dev/null\n@@ -1,2 +0,0 @@\n-x = 1\n-y = 2\n",
+			want:  map[string]patchCounts{"Python": {removed: 2}},
+		},
+		{
+			name:  "строка \\ No newline не входит в хунк",
+			patch: "--- a/a.go\n+++ b/a.go\n@@ -1,2 +1,2 @@\n package a\n-var x = 1\n\\ No newline at end of file\n+var x = 2\n\\ No newline at end of file\n",
+			want:  map[string]patchCounts{"Go": {added: 1, removed: 1}},
+		},
+		{
+			name: "несколько файлов; +++ внутри хунка — добавленная строка",
+			patch: "diff --git a/a.c b/a.c\n--- a/a.c\n+++ b/a.c\n@@ -1,1 +1,2 @@\n int i;\n+++i;\n" +
+				"diff --git a/b.py b/b.py\n--- a/b.py\n+++ b/b.py\n@@ -1,2 +1,1 @@\n-x = 1\n-# комментарий\n" +
+				"diff --git a/c.xyz b/c.xyz\n--- a/c.xyz\n+++ b/c.xyz\n@@ -1 +1 @@\n-a\n+b\n",
+			want: map[string]patchCounts{"C": {added: 1}, "Python": {removed: 1}},
+		},
+		{
+			name:  "изменены только отступы",
+			patch: "--- a/a.go\n+++ b/a.go\n@@ -1,2 +1,2 @@\n-\tx := 1\n-y := 2\n+x := 1\n+y := 3\n",
+			want:  map[string]patchCounts{"Go": {added: 2, removed: 2, whitespace: 1}},
+		},
+		{
+			name:    "переименование git без изменений",
+			patch:   "diff --git a/a.go b/b.go\nsimilarity index 100%\nrename from a.go\nrename to b.go\n",
+			want:    map[string]patchCounts{},
+			renamed: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sum, err := countPatch(strings.NewReader(tt.patch), func(string) bool { return true })
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sum.byLang) != len(tt.want) {
+				t.Errorf("языков %d, ожидалось %d", len(sum.byLang), len(tt.want))
+			}
+			for lang, want := range tt.want {
+				got := sum.byLang[lang]
+				if got == nil || *got != want {
+					t.Errorf("%s: получено %+v, ожидалось %+v", lang, got, want)
+				}
+			}
+			if sum.renamed != tt.renamed {
+				t.Errorf("переименовано %d, ожидалось %d", sum.renamed, tt.renamed)
+			}
+		})
+	}
+}
+
+func TestParseHunkHeader(t *testing.T) {
+	tests := []struct {
+		line           string
+		oldLen, newLen int
+		wantErr        bool
+	}{
+		{line: "@@ -1,3 +1,4 @@", oldLen: 3, newLen: 4},
+		{line: "@@ -5 +5,2 @@ func f() {", oldLen: 1, newLen: 2},
+		{line: "@@ -0,0 +1 @@", oldLen: 0, newLen: 1},
+		{line: "@@ 1,3 +1,4 @@", wantErr: true},
+		{line: "@@ -1,x +1 @@", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		oldLen, newLen, err := parseHunkHeader(tt.line)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: ошибка %v, ожидалась ошибка: %t", tt.line, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (oldLen != tt.oldLen || newLen != tt.newLen) {
+			t.Errorf("%q: получено -%d +%d, ожидалось -%d +%d", tt.line, oldLen, newLen, tt.oldLen, tt.newLen)
+		}
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	tests := []struct {
+		name     string
+		comma    rune
+		quoteAll bool
+		row      []string
+		want     string
+	}{
+		{
+			name:  "кавычки только где нужно",
+			comma: ',',
+			row:   []string{"a.go", "b,c", `d"e`, "", " x", "f\ng"},
+			want:  "a.go,\"b,c\",\"d\"\"e\",,\" x\",\"f\ng\"\n",
+		},
+		{
+			name:  "разделитель «;»: запятая в поле не требует кавычек",
+			comma: ';',
+			row:   []string{"a,b", "c;d", "1"},
+			want:  "a,b;\"c;d\";1\n",
+		},
+		{
+			name:  "табуляция",
+			comma: '\t',
+			row:   []string{"a b", "c\td"},
+			want:  "a b\t\"c\td\"\n",
+		},
+		{
+			name:     "все поля в кавычках",
+			comma:    ',',
+			quoteAll: true,
+			row:      []string{"a", "", `b"`},
+			want:     "\"a\",\"\",\"b\"\"\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := &csvWriter{w: bufio.NewWriter(&buf), comma: tt.comma, quoteAll: tt.quoteAll}
+			w.write(tt.row)
+			w.w.Flush()
+			if buf.String() != tt.want {
+				t.Errorf("получено %q, ожидалось %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWindowHashes(t *testing.T) {
+	if got := windowHashes([]uint64{1, 2}, 3); got != nil {
+		t.Errorf("окно длиннее файла: получено %v, ожидалось nil", got)
+	}
+
+	// Скользящий хеш совпадает с хешем, посчитанным для окна заново
+	lines := []uint64{7, 1, 2, 3, 9, 1, 2, 3}
+	const n = 3
+	got := windowHashes(lines, n)
+	if len(got) != len(lines)-n+1 {
+		t.Fatalf("окон %d, ожидалось %d", len(got), len(lines)-n+1)
+	}
+	for i := range got {
+		if want := windowHashes(lines[i:i+n], n)[0]; got[i] != want {
+			t.Errorf("окно %d: получено %x, ожидалось %x", i, got[i], want)
+		}
+	}
+	if got[1] != got[5] {
+		t.Errorf("одинаковые окна 1 и 5 дали разные хеши")
+	}
+	if got[0] == got[1] {
+		t.Errorf("разные окна 0 и 1 дали одинаковые хеши")
+	}
+}
+
+func TestFindDuplication(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    [][]uint64
+		minLines int
+		want     []int
+	}{
+		{
+			name:     "повтор в другом файле",
+			files:    [][]uint64{{1, 2, 3, 4}, {9, 2, 3, 4, 8}},
+			minLines: 3,
+			want:     []int{3, 3},
+		},
+		{
+			name:     "повтор внутри файла",
+			files:    [][]uint64{{1, 2, 3, 7, 1, 2, 3}},
+			minLines: 3,
+			want:     []int{6},
+		},
+		{
+			name:     "перекрывающиеся окна",
+			files:    [][]uint64{{5, 5, 5, 5}},
+			minLines: 3,
+			want:     []int{4},
+		},
+		{
+			name:     "блок короче порога",
+			files:    [][]uint64{{1, 2}, {1, 2}},
+			minLines: 3,
+			want:     []int{0, 0},
+		},
+		{
+			name:     "уникальный код",
+			files:    [][]uint64{{1, 2, 3}, {4, 5, 6}},
+			minLines: 3,
+			want:     []int{0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rep := &report{}
+			for _, hashes := range tt.files {
+				rep.files = append(rep.files, fileResult{lineHashes: hashes})
+			}
+			rep.findDuplication(tt.minLines)
+			for i, f := range rep.files {
+				if f.duplicated != tt.want[i] {
+					t.Errorf("файл %d: повторяющихся строк %d, ожидалось %d", i, f.duplicated, tt.want[i])
+				}
+				if f.hashedLines != len(tt.files[i]) || f.lineHashes != nil {
+					t.Errorf("файл %d: hashedLines %d, lineHashes %v", i, f.hashedLines, f.lineHashes)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"os"
+	"time"
+)
+
+// contentHasher считает SHA-256 и размер содержимого, прочитанного
+// через io.TeeReader.
+type contentHasher struct {
+	h    hash.Hash
+	size int64
+}
+
+func (c *contentHasher) Write(p []byte) (int, error) {
+	c.size += int64(len(p))
+	return c.h.Write(p)
+}
+
+// manifestReader при --manifest и --dedupe пропускает чтение r через
+// хешер содержимого; без них возвращает r и nil.
+func (o *options) manifestReader(r io.Reader) (io.Reader, *contentHasher) {
+	c := o.contentHasher()
+	if c == nil {
+		return r, nil
+	}
+	return io.TeeReader(r, c), c
+}
+
+// contentHasher возвращает новый хешер содержимого при --manifest
+// и --dedupe и nil без них.
+func (o *options) contentHasher() *contentHasher {
+	if o.manifest == "" && !o.dedupe {
+		return nil
+	}
+	return &contentHasher{h: sha256.New()}
+}
+
+// finish дочитывает r до конца, чтобы хеш покрыл всё содержимое,
+// и записывает хеш и размер в res. Нулевой хешер ничего не делает.
+func (c *contentHasher) finish(r io.Reader, res *fileResult) error {
+	if c == nil {
+		return nil
+	}
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return err
+	}
+	c.record(res)
+	return nil
+}
+
+// record записывает хеш и размер прочитанного содержимого в res.
+// Нулевой хешер ничего не делает.
+func (c *contentHasher) record(res *fileResult) {
+	if c == nil {
+		return
+	}
+	res.sha256 = hex.EncodeToString(c.h.Sum(nil))
+	res.size = c.size
+}
+
+// hashFile записывает в res SHA-256 и размер файла на диске — для файлов,
+// итоги которых взяты из контрольной точки без чтения (--resume).
+func hashFile(name string, res *fileResult) error {
+	f, err := os.Open(longPath(name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	c := &contentHasher{h: sha256.New()}
+	if _, err := io.Copy(c, f); err != nil {
+		return err
+	}
+	c.record(res)
+	return nil
+}
+
+// manifestFile — запись о файле в манифесте.
+type manifestFile struct {
+	Path     string `json:"path"`
+	Language string `json:"language"`
+	Lines    int    `json:"lines"`
+	Size     int64  `json:"size"`
+	SHA256   string `json:"sha256"`
+}
+
+// manifest — перечень посчитанных файлов с хешами содержимого, по которому
+// итоги подсчёта можно сверить с точным состоянием файлов на момент обхода.
+type manifest struct {
+	Tool       string         `json:"tool"`
+	Version    string         `json:"version"`
+	Created    time.Time      `json:"created"`
+	Root       string         `json:"root,omitempty"`
+	Files      []manifestFile `json:"files"`
+	TotalFiles int            `json:"total_files"`
+	TotalLines int            `json:"total_lines"`
+	Incomplete bool           `json:"incomplete,omitempty"`
+}
+
+// writeManifest сохраняет манифест отчёта rep в файл name в формате JSON.
+func writeManifest(name string, rep *report) error {
+	m := manifest{
+		Tool:       "loc_counter",
+		Version:    version,
+		Created:    time.Now().UTC().Truncate(time.Second),
+		Root:       rep.root,
+		Files:      make([]manifestFile, 0, len(rep.files)),
+		TotalFiles: len(rep.files),
+		TotalLines: rep.totalLines,
+		Incomplete: rep.incomplete,
+	}
+	for _, f := range rep.files {
+		m.Files = append(m.Files, manifestFile{f.path, f.lang, f.lines, f.size, f.sha256})
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// commitInfo — последний коммит, изменивший файл.
+type commitInfo struct {
+	author string
+	date   string // в формате YYYY-MM-DD
+}
+
+// lastCommits возвращает последний коммит для каждого файла под dir
+// (пути относительно dir). История читается одним вызовом git log,
+// а не по вызову на файл. Вне git-репозитория возвращает nil.
+func lastCommits(dir string) map[string]commitInfo {
+	out, err := runGit(dir, "-c", "core.quotePath=false", "log",
+		"--format=\x01%an\t%as", "--name-only", "--no-renames", "--relative")
+	if err != nil {
+		return nil
+	}
+
+	// Коммиты идут от новых к старым, поэтому для каждого файла
+	// запоминается только первое вхождение
+	commits := make(map[string]commitInfo)
+	var cur commitInfo
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if header, ok := strings.CutPrefix(line, "\x01"); ok {
+			cur.author, cur.date, _ = strings.Cut(header, "\t")
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		if _, seen := commits[line]; !seen {
+			commits[line] = cur
+		}
+	}
+	return commits
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// moduleManifests — файлы, обозначающие корень отдельно выпускаемого модуля.
+var moduleManifests = []string{"go.mod", "Cargo.toml", "package.json"}
+
+// moduleManifest возвращает имя файла-манифеста в директории dir
+// или пустую строку, если директория не является корнем модуля.
+func moduleManifest(dir string) string {
+	for _, m := range moduleManifests {
+		if info, err := os.Stat(filepath.Join(dir, m)); err == nil && !info.IsDir() {
+			return m
+		}
+	}
+	return ""
+}
+
+// moduleOf возвращает корень ближайшего модуля, содержащего путь relPath
+// (путь файла относительно корня обхода), или пустую строку.
+func moduleOf(relPath string, modules map[string]string) string {
+	for p := path.Dir(relPath); ; p = path.Dir(p) {
+		if _, ok := modules[p]; ok {
+			return p
+		}
+		if p == "." || p == "/" {
+			return ""
+		}
+	}
+}
+
+// subtotal — промежуточный итог по группе файлов (подмодулю, модулю).
+type subtotal struct {
+	files   int
+	lines   int
+	imports int // заполняется только в сводках по языкам и расширениям
+
+	// comments и blanks — строки комментариев и пустые строки; заполняются
+	// только в сводках по языкам и расширениям
+	comments, blanks int
+}
+
+func (t *subtotal) add(lines int) {
+	t.files++
+	t.lines += lines
+}
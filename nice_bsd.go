@@ -0,0 +1,10 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package main
+
+import "syscall"
+
+// lowerPriority понижает приоритет процесса по CPU (nice 19).
+func lowerPriority() error {
+	return syscall.Setpriority(syscall.PRIO_PROCESS, 0, 19)
+}
@@ -0,0 +1,24 @@
+package main
+
+import "syscall"
+
+// Константы ioprio_set(2): класс IDLE получает доступ к диску
+// только когда его не запрашивают другие процессы.
+const (
+	ioprioWhoProcess = 1
+	ioprioClassIdle  = 3
+	ioprioClassShift = 13
+)
+
+// lowerPriority понижает приоритет процесса по CPU (nice 19)
+// и переводит его ввод-вывод в класс IDLE.
+func lowerPriority() error {
+	if err := syscall.Setpriority(syscall.PRIO_PROCESS, 0, 19); err != nil {
+		return err
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, 0, ioprioClassIdle<<ioprioClassShift)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !windows && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package main
+
+import "errors"
+
+// lowerPriority на прочих платформах не поддерживается.
+func lowerPriority() error {
+	return errors.New(tr("понижение приоритета не поддерживается на этой платформе"))
+}
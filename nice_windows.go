@@ -0,0 +1,24 @@
+package main
+
+import "syscall"
+
+// PROCESS_MODE_BACKGROUND_BEGIN понижает приоритет процесса по CPU,
+// вводу-выводу и памяти одновременно.
+const processModeBackgroundBegin = 0x00100000
+
+var (
+	kernel32             = syscall.NewLazyDLL("kernel32.dll")
+	procSetPriorityClass = kernel32.NewProc("SetPriorityClass")
+)
+
+// lowerPriority переводит процесс в фоновый режим.
+func lowerPriority() error {
+	h, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return err
+	}
+	if r, _, err := procSetPriorityClass.Call(uintptr(h), processModeBackgroundBegin); r == 0 {
+		return err
+	}
+	return nil
+}
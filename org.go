@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const orgSynopsis = "loc_counter org github.com/организация|gitlab.com/группа [--token ...]"
+
+// orgFlags объявляет флаги подкоманды org; фильтры путей попадают в opts.
+func orgFlags(fs *flag.FlagSet, opts *options) (token, api *string, skipArchived *bool) {
+	token = fs.String("token", "", "Токен API. По умолчанию: $GITHUB_TOKEN или $GITLAB_TOKEN.")
+	api = fs.String("api", "", "Адрес API (для GitHub Enterprise или своего GitLab). По умолчанию определяется по хосту.")
+	skipArchived = fs.Bool("skip-archived", false, "Не учитывать архивные репозитории.")
+	fs.Var(&opts.exclude, "exclude", "Директории для исключения (например, --exclude vendor).")
+	fs.Var(&opts.ext, "ext", "Расширения для включения. По умолчанию: все поддерживаемые.")
+	fs.Var(&opts.extExclude, "ext-exclude", "Расширения для исключения.")
+	return token, api, skipArchived
+}
+
+// runOrg реализует подкоманду org: перечисляет все репозитории организации
+// GitHub или группы GitLab, подсчитывает каждый через API архивов (без git)
+// и выводит сводку по репозиториям и языкам.
+func runOrg(args []string) {
+	fs := flag.NewFlagSet("org", flag.ExitOnError)
+	var opts options
+	token, api, skipArchived := orgFlags(fs, &opts)
+	lang := langFlag(fs)
+	fs.Usage = commandUsage(fs, orgSynopsis)
+	fs.Parse(args)
+
+	// Флаги допускаются и после адреса организации
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	target := fs.Arg(0)
+	fs.Parse(fs.Args()[1:])
+	if fs.NArg() > 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	setLocale(*lang)
+
+	host, org, ok := strings.Cut(strings.TrimSuffix(target, "/"), "/")
+	if !ok || org == "" {
+		fmt.Fprintf(os.Stderr, tr("ошибка: ожидается хост/организация (например, github.com/myorg), получено %q\n"), target)
+		os.Exit(2)
+	}
+
+	// GitHub — github.com или GitHub Enterprise (API по адресу .../api/v3),
+	// всё остальное считается GitLab
+	var src orgSource
+	if host == "github.com" || strings.HasSuffix(strings.TrimSuffix(*api, "/"), "/api/v3") {
+		if *api == "" {
+			*api = defaultGitHubAPI
+		}
+		src = &githubOrg{client: newGitHubClient(*api, *token), org: org}
+	} else {
+		if *api == "" {
+			*api = "https://" + host + "/api/v4"
+		}
+		if *token == "" {
+			*token = os.Getenv("GITLAB_TOKEN")
+		}
+		src = &gitlabGroup{api: strings.TrimSuffix(*api, "/"), token: *token, group: org}
+	}
+
+	repos, err := src.repos(*skipArchived)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+		os.Exit(1)
+	}
+	if len(repos) == 0 {
+		fmt.Printf(tr("В %s нет репозиториев.\n"), target)
+		return
+	}
+
+	rep := scanRepos(repos, func(repo string) (*report, error) {
+		return src.scan(repo, &opts)
+	})
+
+	fmt.Println()
+	fmt.Printf(tr("%s: репозиториев %d, файлов %d, строк кода %d\n"), target, len(rep.repos), len(rep.files), rep.totalLines)
+	fmt.Println()
+	printSubtotals(tr("Репозиторий"), rep.repos, nil)
+	printLanguages(rep, &opts)
+	rep.printSkipped()
+
+	if rep.incomplete {
+		os.Exit(exitInterrupted)
+	}
+}
+
+// orgSource — хостинг, из которого подкоманда org берёт репозитории.
+type orgSource interface {
+	// repos возвращает полные имена всех репозиториев организации.
+	repos(skipArchived bool) ([]string, error)
+	// scan подсчитывает строки в репозитории по его полному имени.
+	scan(repo string, opts *options) (*report, error)
+}
+
+// githubOrg — организация GitHub.
+type githubOrg struct {
+	client *githubClient
+	org    string
+}
+
+func (g *githubOrg) repos(skipArchived bool) ([]string, error) {
+	var names []string
+	for page := 1; ; page++ {
+		var batch []struct {
+			FullName string `json:"full_name"`
+			Archived bool   `json:"archived"`
+		}
+		err := getJSON(g.client.get, "/orgs/"+g.org+"/repos?per_page=100&page="+strconv.Itoa(page), &batch)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			return names, nil
+		}
+		for _, r := range batch {
+			if !(skipArchived && r.Archived) {
+				names = append(names, r.FullName)
+			}
+		}
+	}
+}
+
+func (g *githubOrg) scan(repo string, opts *options) (*report, error) {
+	return scanGitHub(g.client, repo, opts)
+}
+
+// gitlabGroup — группа GitLab вместе с подгруппами.
+type gitlabGroup struct {
+	api   string
+	token string
+	group string
+}
+
+func (g *gitlabGroup) get(path string) (io.ReadCloser, error) {
+	var header http.Header
+	if g.token != "" {
+		header = http.Header{"Private-Token": {g.token}}
+	}
+	return httpGet(g.api+path, header)
+}
+
+func (g *gitlabGroup) repos(skipArchived bool) ([]string, error) {
+	var names []string
+	for page := 1; ; page++ {
+		var batch []struct {
+			Path     string `json:"path_with_namespace"`
+			Archived bool   `json:"archived"`
+		}
+		err := getJSON(g.get, "/groups/"+url.PathEscape(g.group)+"/projects?include_subgroups=true&per_page=100&page="+strconv.Itoa(page), &batch)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			return names, nil
+		}
+		for _, r := range batch {
+			if !(skipArchived && r.Archived) {
+				names = append(names, r.Path)
+			}
+		}
+	}
+}
+
+func (g *gitlabGroup) scan(repo string, opts *options) (*report, error) {
+	body, err := g.get("/projects/" + url.PathEscape(repo) + "/repository/archive.tar.gz")
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return scanTarball(body, opts)
+}
+
+// getJSON запрашивает path функцией get и разбирает ответ как JSON в v.
+func getJSON(get func(string) (io.ReadCloser, error), path string, v any) error {
+	body, err := get(path)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// formatTarget — один вывод отчёта: формат и файл, куда он записывается.
+// Пустой path — stdout.
+type formatTarget struct {
+	format string
+	path   string
+	output func(*report, *options)
+	file   *os.File
+}
+
+// formatList — значения повторяемого флага --format: «формат» или
+// «формат:путь». Формат без пути выводится в stdout (или в --out),
+// остальные записываются в свои файлы за тот же обход.
+type formatList []formatTarget
+
+func (l *formatList) String() string {
+	parts := make([]string, 0, len(*l))
+	for _, t := range *l {
+		if t.path != "" {
+			parts = append(parts, t.format+":"+t.path)
+		} else {
+			parts = append(parts, t.format)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func (l *formatList) Set(v string) error {
+	// Имена форматов не содержат двоеточия, поэтому путь вида C:\report.json
+	// отделяется по первому из них
+	format, path, hasPath := strings.Cut(v, ":")
+	if format == "" {
+		return errors.New(tr("не указан формат"))
+	}
+	if hasPath && path == "" {
+		return fmt.Errorf(tr("не указан путь после %q"), format+":")
+	}
+	*l = append(*l, formatTarget{format: format, path: path})
+	return nil
+}
+
+// setupOutputs проверяет сочетание --format, --out, --compat и --template
+// и выбирает функцию вывода для каждого формата.
+func (o *options) setupOutputs() error {
+	console := 0
+	for _, t := range o.formats {
+		if t.path == "" {
+			console++
+		}
+	}
+	if console > 1 {
+		return errors.New(tr("--format без пути можно указать только один раз, остальные форматы записываются в файлы (например, --format json:report.json)"))
+	}
+
+	if o.template != "" {
+		if console > 0 || o.compat != "" {
+			return errors.New(tr("--template задаёт формат вывода сам и не сочетается с --format без пути и --compat"))
+		}
+		tmpl, err := loadTemplate(o.template)
+		if err != nil {
+			return fmt.Errorf("--template: %v", err)
+		}
+		o.formats = append(o.formats, formatTarget{format: "template", output: templateOutput(tmpl)})
+	} else if console == 0 && (len(o.formats) == 0 || o.out != "") {
+		o.formats = append(o.formats, formatTarget{format: "table"})
+	}
+
+	for i := range o.formats {
+		t := &o.formats[i]
+		if t.path == "" {
+			t.path = o.out
+		}
+		if t.format == "ndjson" && len(o.formats) > 1 {
+			return errors.New(tr("--format ndjson не сочетается с другими форматами: файлы не хранятся до конца подсчёта"))
+		}
+		if t.output != nil {
+			continue
+		}
+		output, err := o.outputFor(t)
+		if err != nil {
+			return err
+		}
+		t.output = output
+	}
+
+	// --compat заменяет схему JSON на схему другой утилиты
+	switch o.compat {
+	case "":
+	case "tokei":
+		for i := range o.formats {
+			t := &o.formats[i]
+			if t.format != "table" && t.format != "json" {
+				return errors.New(tr("--compat tokei выводит JSON и не сочетается с другими форматами"))
+			}
+			t.output = printTokei
+		}
+	default:
+		return fmt.Errorf(tr("неизвестное значение --compat %q (ожидается tokei)"), o.compat)
+	}
+	return nil
+}
+
+// outputFor возвращает функцию вывода формата t.
+func (o *options) outputFor(t *formatTarget) (func(*report, *options), error) {
+	switch t.format {
+	case "table":
+		return printText, nil
+	case "json":
+		return printJSON, nil
+	case "csv":
+		return printCSV, nil
+	case "tsv":
+		return printTSV, nil
+	case "xml":
+		return printXML, nil
+	case "markdown":
+		return printMarkdown, nil
+	case "html":
+		return printHTML, nil
+	case "sqlite":
+		if t.path == "" {
+			return nil, errors.New(tr("--format sqlite требует указать файл базы: --format sqlite:stats.db или --out stats.db"))
+		}
+		return sqliteOutput(t.path), nil
+	case "ndjson":
+		if o.repos != "" || o.manifest != "" || o.chartOut != "" {
+			return nil, errors.New(tr("--format ndjson нельзя сочетать с --repos, --manifest и --chart-out: файлы не хранятся до конца подсчёта"))
+		}
+		return printNDJSON, nil
+	case "quickfix", "junit":
+		if o.maxLines <= 0 {
+			return nil, fmt.Errorf(tr("--format %s требует указать лимит через --max-lines"), t.format)
+		}
+		if t.format == "junit" {
+			return printJUnit, nil
+		}
+		return printQuickfix, nil
+	}
+	return nil, fmt.Errorf(tr("неизвестный формат %q (ожидается table, markdown, html, json, ndjson, csv, tsv, xml, sqlite, quickfix или junit)"), t.format)
+}
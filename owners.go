@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// codeownersPaths — где GitHub и GitLab ищут файл CODEOWNERS, в порядке поиска.
+var codeownersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// ownerRule — правило CODEOWNERS: шаблон пути и владельцы.
+type ownerRule struct {
+	pattern *regexp.Regexp
+	owners  string // владельцы через пробел; пусто — правило снимает владельцев
+}
+
+// loadCodeowners читает правила CODEOWNERS репозитория dir и возвращает их
+// вместе с путём к файлу; если файла нет, путь пустой.
+func loadCodeowners(dir string) ([]ownerRule, string, error) {
+	for _, p := range codeownersPaths {
+		name := filepath.Join(dir, filepath.FromSlash(p))
+		f, err := os.Open(name)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, name, err
+		}
+		defer f.Close()
+
+		var rules []ownerRule
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			// Секции GitLab ([Раздел]) на сопоставление не влияют
+			if strings.HasPrefix(line, "[") || strings.HasPrefix(line, "^[") {
+				continue
+			}
+			fields := strings.Fields(line)
+			re, err := codeownersPattern(fields[0])
+			if err != nil {
+				continue
+			}
+			rules = append(rules, ownerRule{pattern: re, owners: strings.Join(fields[1:], " ")})
+		}
+		return rules, name, scanner.Err()
+	}
+	return nil, "", nil
+}
+
+// codeownersPattern переводит шаблон CODEOWNERS (синтаксис gitignore)
+// в регулярное выражение для пути относительно корня со слешами.
+// Шаблон без слеша в середине совпадает на любой глубине, шаблон
+// директории — со всем её содержимым.
+func codeownersPattern(pattern string) (*regexp.Regexp, error) {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	p := strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	if !anchored {
+		sb.WriteString("(.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		switch {
+		case strings.HasPrefix(p[i:], "**/"):
+			sb.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case p[i] == '*':
+			sb.WriteString("[^/]*")
+		case p[i] == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		}
+	}
+	if dirOnly {
+		sb.WriteString("/.*$")
+	} else {
+		sb.WriteString("(/.*)?$")
+	}
+	return regexp.Compile(sb.String())
+}
+
+// ownerOf возвращает владельцев файла relPath: по правилам CODEOWNERS
+// действует последнее совпавшее.
+func ownerOf(relPath string, rules []ownerRule) string {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].pattern.MatchString(relPath) {
+			return rules[i].owners
+		}
+	}
+	return ""
+}
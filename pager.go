@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// pager накапливает вывод отчёта и, если он не помещается на экран
+// терминала, показывает его через $PAGER — как это делает git.
+type pager struct {
+	stdout *os.File // настоящий stdout
+	r, w   *os.File
+	buf    bytes.Buffer
+	done   chan struct{}
+}
+
+// isTerminal сообщает, подключён ли файл к терминалу.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// startPager перенаправляет os.Stdout в буфер, если stdout — терминал.
+// Если постраничный вывод не нужен, возвращает nil; вызывать finish
+// у nil-значения безопасно.
+func startPager() *pager {
+	if !isTerminal(os.Stdout) {
+		return nil
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil
+	}
+
+	p := &pager{stdout: os.Stdout, r: r, w: w, done: make(chan struct{})}
+	go func() {
+		io.Copy(&p.buf, r)
+		close(p.done)
+	}()
+	os.Stdout = w
+	return p
+}
+
+// finish восстанавливает stdout и выводит накопленный отчёт: напрямую,
+// если он помещается на экран, иначе через пейджер. Если пейджер
+// не удалось запустить, отчёт тоже выводится напрямую.
+func (p *pager) finish() {
+	if p == nil {
+		return
+	}
+	os.Stdout = p.stdout
+	p.w.Close()
+	<-p.done
+	p.r.Close()
+
+	if bytes.Count(p.buf.Bytes(), []byte("\n")) < terminalHeight(p.stdout) {
+		p.stdout.Write(p.buf.Bytes())
+		return
+	}
+
+	args := strings.Fields(pagerCommand())
+	if len(args) == 0 {
+		p.stdout.Write(p.buf.Bytes())
+		return
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(p.buf.Bytes())
+	cmd.Stdout = p.stdout
+	cmd.Stderr = os.Stderr
+	// Как git: выйти, если отчёт помещается на экран, сохранить цвета
+	// и не очищать экран после выхода
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	if err := cmd.Start(); err != nil {
+		p.stdout.Write(p.buf.Bytes())
+		return
+	}
+	cmd.Wait()
+}
+
+// pagerCommand возвращает команду пейджера: LOC_COUNTER_PAGER, затем PAGER,
+// иначе стандартный для платформы.
+func pagerCommand() string {
+	for _, env := range []string{"LOC_COUNTER_PAGER", "PAGER"} {
+		if v, ok := os.LookupEnv(env); ok {
+			return v
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return "more"
+	}
+	return "less"
+}
+
+// terminalHeight возвращает высоту терминала: из системы, из $LINES
+// или 24 строки по умолчанию.
+func terminalHeight(f *os.File) int {
+	if h := consoleHeight(f); h > 0 {
+		return h
+	}
+	if h, err := strconv.Atoi(os.Getenv("LINES")); err == nil && h > 0 {
+		return h
+	}
+	return 24
+}
@@ -0,0 +1,458 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// patchCounts — добавленные и удалённые строки кода одного языка.
+type patchCounts struct {
+	added   int
+	removed int
+	// whitespace — пары удалённой и добавленной строки кода, которые
+	// различаются только пробелами и отступами (входят в added и removed)
+	whitespace int
+}
+
+// changeBlock — подряд идущие удалённые и добавленные строки кода хунка
+// без пробельных символов, для поиска изменений только в пробелах.
+type changeBlock struct {
+	removed, added []string
+}
+
+// whitespacePairs возвращает число добавленных строк блока, у которых
+// есть парная удалённая строка, отличающаяся только пробелами, и очищает блок.
+func (b *changeBlock) whitespacePairs() int {
+	left := make(map[string]int, len(b.removed))
+	for _, s := range b.removed {
+		left[s]++
+	}
+	n := 0
+	for _, s := range b.added {
+		if left[s] > 0 {
+			left[s]--
+			n++
+		}
+	}
+	b.removed, b.added = b.removed[:0], b.added[:0]
+	return n
+}
+
+// patchSummary — итог разбора патча.
+type patchSummary struct {
+	byLang  map[string]*patchCounts
+	renamed int // переименованные и перемещённые файлы
+}
+
+// wholeFile — файл, который патч добавляет или удаляет целиком: строки
+// его кода без пробельных символов нужны, чтобы найти переименования
+// в патчах без заголовков rename (diff -u, git diff --no-renames).
+type wholeFile struct {
+	counts *patchCounts
+	lines  []string
+	paired bool
+}
+
+// pairRenames сопоставляет удалённые и добавленные файлы одного языка
+// по совпадению строк кода: пара, у которой совпадает не меньше половины
+// строк большего файла, считается переименованием, и совпавшие строки
+// вычитаются из добавленных и удалённых. Возвращает число пар.
+func pairRenames(deleted, added []*wholeFile) int {
+	pairs := 0
+	for _, a := range added {
+		var best *wholeFile
+		bestCommon := 0
+		for _, d := range deleted {
+			if d.paired || d.counts != a.counts {
+				continue
+			}
+			common := commonLines(d.lines, a.lines)
+			if common*2 >= max(len(d.lines), len(a.lines)) && common > bestCommon {
+				best, bestCommon = d, common
+			}
+		}
+		if best == nil {
+			continue
+		}
+		best.paired = true
+		a.counts.added -= bestCommon
+		best.counts.removed -= bestCommon
+		pairs++
+	}
+	return pairs
+}
+
+// commonLines возвращает число совпадающих строк двух файлов без учёта
+// порядка.
+func commonLines(a, b []string) int {
+	left := make(map[string]int, len(a))
+	for _, s := range a {
+		left[s]++
+	}
+	n := 0
+	for _, s := range b {
+		if left[s] > 0 {
+			left[s]--
+			n++
+		}
+	}
+	return n
+}
+
+// stripSpace убирает из строки все пробельные символы, как git diff -w.
+func stripSpace(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
+
+// countPatch подсчитывает добавленные и удалённые строки кода в unified diff
+// (вывод git diff, diff -u) по языкам. Файлы, для которых accept возвращает
+// false, и файлы неподдерживаемых языков пропускаются.
+//
+// Строки хунка классифицируются теми же правилами комментариев, что и файлы
+// целиком. Для старой и новой версии файла ведётся отдельное состояние
+// блочного комментария: контекстные строки попадают в обе версии.
+// Хунк начинается с неизвестным состоянием, поэтому если он открывается
+// посреди блочного комментария, первые его строки будут сочтены кодом.
+//
+// Удалённые и добавленные строки кода между контекстными строками
+// сопоставляются: пары, различающиеся только пробелами, учитываются
+// ещё и в patchCounts.whitespace.
+//
+// Переименования не считаются добавлением и удалением всего файла:
+// git с заголовками rename from/rename to показывает только изменённые
+// строки, а удалённый и добавленный файлы с преимущественно одинаковым
+// кодом сопоставляются по содержимому (см. pairRenames).
+func countPatch(r io.Reader, accept func(ext string) bool) (*patchSummary, error) {
+	byLang := make(map[string]*patchCounts)
+	sum := &patchSummary{byLang: byLang}
+	var deleted, added []*wholeFile
+	var whole *wholeFile // текущий файл, если патч добавляет или удаляет его целиком
+
+	var cur *patchCounts // nil — текущий файл не учитывается
+	var cfg LangConfig
+	var oldPath string
+	var oldSide, newSide lineClassifier
+	oldLeft, newLeft := 0, 0 // строки, оставшиеся в текущем хунке
+	var block changeBlock
+	flush := func() {
+		if cur != nil {
+			cur.whitespace += block.whitespacePairs()
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Внутри хунка заголовки не распознаются: строка "+++ x"
+		// здесь — это добавленная строка "++ x"
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				newLeft--
+				if cur != nil && newSide.classify(line[1:]) == lineCode {
+					cur.added++
+					block.added = append(block.added, stripSpace(line[1:]))
+					if whole != nil {
+						whole.lines = append(whole.lines, stripSpace(line[1:]))
+					}
+				}
+			case strings.HasPrefix(line, "-"):
+				oldLeft--
+				if cur != nil && oldSide.classify(line[1:]) == lineCode {
+					cur.removed++
+					block.removed = append(block.removed, stripSpace(line[1:]))
+					if whole != nil {
+						whole.lines = append(whole.lines, stripSpace(line[1:]))
+					}
+				}
+			case strings.HasPrefix(line, `\`):
+				// "\ No newline at end of file"
+			default:
+				// Контекстная строка (пустая строка контекста может
+				// потерять ведущий пробел при копировании патча)
+				flush()
+				oldLeft--
+				newLeft--
+				if cur != nil {
+					content := strings.TrimPrefix(line, " ")
+					oldSide.classify(content)
+					newSide.classify(content)
+				}
+			}
+			continue
+		}
+		flush()
+
+		switch {
+		case strings.HasPrefix(line, "diff "):
+			// Начало следующего файла в выводе git diff
+			cur, whole = nil, nil
+
+		case strings.HasPrefix(line, "rename to "):
+			// Переименование, найденное git: хунки ниже (если они есть)
+			// содержат только изменённые строки
+			sum.renamed++
+
+		case strings.HasPrefix(line, "--- "):
+			oldPath = patchPath(line[4:])
+
+		case strings.HasPrefix(line, "+++ "):
+			// У удалённого файла новый путь — /dev/null, язык берётся по старому
+			path := patchPath(line[4:])
+			isDeleted, isAdded := path == "", oldPath == ""
+			if isDeleted {
+				path = oldPath
+			}
+			cur, whole = nil, nil
+			ext := strings.ToLower(filepath.Ext(path))
+			c, ok := languageByName(filepath.Base(path))
+			if !ok || !accept(ext) {
+				continue
+			}
+			cfg = c
+			if byLang[cfg.Name] == nil {
+				byLang[cfg.Name] = &patchCounts{}
+			}
+			cur = byLang[cfg.Name]
+			switch {
+			case isDeleted:
+				whole = &wholeFile{counts: cur}
+				deleted = append(deleted, whole)
+			case isAdded:
+				whole = &wholeFile{counts: cur}
+				added = append(added, whole)
+			}
+
+		case strings.HasPrefix(line, "@@ "):
+			var err error
+			if oldLeft, newLeft, err = parseHunkHeader(line); err != nil {
+				return nil, err
+			}
+			oldSide = lineClassifier{cfg: cfg}
+			newSide = lineClassifier{cfg: cfg}
+		}
+	}
+
+	flush()
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sum.renamed += pairRenames(deleted, added)
+	return sum, nil
+}
+
+// patchPath извлекает путь из строки заголовка "--- a/path" / "+++ b/path".
+// Для /dev/null возвращается пустая строка.
+func patchPath(s string) string {
+	// После пути может идти табуляция и метка времени (diff -u)
+	if i := strings.IndexByte(s, '\t'); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimSpace(s)
+	if s == "/dev/null" {
+		return ""
+	}
+	if unq, err := strconv.Unquote(s); err == nil {
+		s = unq
+	}
+	// Префиксы a/ и b/ git добавляет к путям по умолчанию
+	if strings.HasPrefix(s, "a/") || strings.HasPrefix(s, "b/") {
+		s = s[2:]
+	}
+	return s
+}
+
+// parseHunkHeader разбирает "@@ -a,b +c,d @@" и возвращает число строк
+// старой (b) и новой (d) версии в хунке. Опущенное количество означает 1.
+func parseHunkHeader(line string) (int, int, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return 0, 0, fmt.Errorf(tr("некорректный заголовок хунка: %q"), line)
+	}
+	oldLen, err := hunkRangeLen(fields[1], "-")
+	if err != nil {
+		return 0, 0, fmt.Errorf(tr("некорректный заголовок хунка: %q"), line)
+	}
+	newLen, err := hunkRangeLen(fields[2], "+")
+	if err != nil {
+		return 0, 0, fmt.Errorf(tr("некорректный заголовок хунка: %q"), line)
+	}
+	return oldLen, newLen, nil
+}
+
+func hunkRangeLen(r, sign string) (int, error) {
+	r, ok := strings.CutPrefix(r, sign)
+	if !ok {
+		return 0, fmt.Errorf(tr("ожидался %q"), sign)
+	}
+	_, length, found := strings.Cut(r, ",")
+	if !found {
+		return 1, nil
+	}
+	return strconv.Atoi(length)
+}
+
+// patchJSON — вывод --patch в формате json.
+type patchJSON struct {
+	Languages []patchLangJSON `json:"languages"`
+	Total     patchLangJSON   `json:"total"`
+	Renamed   int             `json:"renamed"`
+}
+
+type patchLangJSON struct {
+	Language   string `json:"language,omitempty"`
+	Added      int    `json:"added"`
+	Removed    int    `json:"removed"`
+	Whitespace int    `json:"whitespace_only,omitempty"`
+}
+
+// checkPatchFormats проверяет, что --format и --compat совместимы
+// с --patch: отчёт по патчу выводится только таблицей или JSON.
+func checkPatchFormats(opts *options) error {
+	if opts.compat != "" {
+		return errors.New(tr("--patch не сочетается с --compat"))
+	}
+	for _, t := range opts.formats {
+		if t.format != "table" && t.format != "json" {
+			return fmt.Errorf(tr("--patch выводит только форматы table и json, а не %q"), t.format)
+		}
+	}
+	return nil
+}
+
+// runPatch читает патч из файла --patch (или stdin при "-") и выводит
+// добавленные и удалённые строки кода по языкам в форматах --format
+// (table или json) в stdout или файлы. Если патч добавляет больше
+// --max-added строк кода, с --fail-over программа завершается с кодом
+// exitOverLimit.
+//
+// С --ignore-whitespace строки, изменённые только в пробелах и отступах,
+// не входят в добавленные и удалённые, а выводятся отдельным столбцом.
+func runPatch(opts *options) {
+	if err := checkPatchFormats(opts); err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+		os.Exit(2)
+	}
+
+	var r io.Reader = os.Stdin
+	if opts.patch != "-" {
+		f, err := os.Open(opts.patch)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	sum, err := countPatch(r, opts.acceptExt)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка разбора патча: %v\n"), err)
+		os.Exit(1)
+	}
+
+	byLang := sum.byLang
+	langs := make([]string, 0, len(byLang))
+	for lang := range byLang {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	var total patchCounts
+	for _, lang := range langs {
+		c := byLang[lang]
+		if opts.ignoreSpace {
+			c.added -= c.whitespace
+			c.removed -= c.whitespace
+		} else {
+			c.whitespace = 0
+		}
+		total.added += c.added
+		total.removed += c.removed
+		total.whitespace += c.whitespace
+	}
+
+	for _, t := range opts.formats {
+		print := func() { printPatchTable(sum, langs, total, opts.ignoreSpace) }
+		if t.format == "json" {
+			print = func() { printPatchJSON(sum, langs, total) }
+		}
+		if t.path == "" {
+			print()
+			continue
+		}
+		f, err := os.Create(t.path)
+		if err == nil {
+			err = writeOutput(f, print)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, tr("ошибка сохранения отчёта %s: %v\n"), t.path, err)
+			os.Exit(1)
+		}
+	}
+
+	if opts.maxAdded > 0 && total.added > opts.maxAdded {
+		fmt.Fprintf(os.Stderr, tr("Патч добавляет %d строк кода — больше лимита --max-added %d\n"), total.added, opts.maxAdded)
+		if opts.failOver {
+			os.Exit(exitOverLimit)
+		}
+	}
+}
+
+// printPatchTable выводит таблицу добавленных и удалённых строк по языкам;
+// с whitespace — и столбец строк, изменённых только в пробелах.
+func printPatchTable(sum *patchSummary, langs []string, total patchCounts, whitespace bool) {
+	if len(langs) == 0 {
+		fmt.Println(tr("В патче нет изменений в поддерживаемых исходных файлах."))
+		return
+	}
+	cells := func(name string, c patchCounts) []string {
+		row := []string{name, "+" + strconv.Itoa(c.added), "-" + strconv.Itoa(c.removed)}
+		if whitespace {
+			row = append(row, strconv.Itoa(c.whitespace))
+		}
+		return row
+	}
+	headers := []string{tr("Язык"), tr("Добавлено"), tr("Удалено")}
+	if whitespace {
+		headers = append(headers, tr("Только пробелы"))
+	}
+	rows := make([][]string, 0, len(langs))
+	for _, lang := range langs {
+		rows = append(rows, cells(lang, *sum.byLang[lang]))
+	}
+	fmt.Println()
+	printTable(headers, rows, cells(tr("Итого"), total))
+	if sum.renamed > 0 {
+		fmt.Printf(tr("Переименовано или перемещено файлов: %d (неизменённые строки не учитываются)\n"), sum.renamed)
+		fmt.Println()
+	}
+}
+
+// printPatchJSON выводит изменения по языкам в JSON.
+func printPatchJSON(sum *patchSummary, langs []string, total patchCounts) {
+	out := patchJSON{
+		Renamed:   sum.renamed,
+		Languages: make([]patchLangJSON, 0, len(langs)),
+		Total:     patchLangJSON{Added: total.added, Removed: total.removed, Whitespace: total.whitespace},
+	}
+	for _, lang := range langs {
+		c := sum.byLang[lang]
+		out.Languages = append(out.Languages, patchLangJSON{Language: lang, Added: c.added, Removed: c.removed, Whitespace: c.whitespace})
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+	}
+}
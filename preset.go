@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// builtinPresets — готовые наборы фильтров для типичных запусков: аргументы
+// командной строки, которые --preset подставляет перед остальными флагами.
+// Пользовательские наборы из файла presets (см. presetsPath) дополняют
+// и переопределяют встроенные.
+var builtinPresets = map[string]string{
+	"web": "--ext .js,.jsx,.mjs,.cjs,.ts,.tsx,.vue,.svelte,.html,.htm,.css,.scss,.less " +
+		"--exclude node_modules,bower_components,dist,build,vendor " +
+		"--exclude-file *.min.js,*.min.css,*.bundle.js",
+	"backend": "--ext .go,.java,.kt,.scala,.py,.rb,.php,.rs,.cs,.c,.h,.cpp,.cc,.cxx,.hpp " +
+		"--exclude vendor,target,bin,obj,build,.venv,venv,__pycache__,node_modules " +
+		"--exclude-file *.pb.go,*_gen.go,*_pb2.py",
+	"infra": "--ext .tf,.tfvars,.hcl,.nomad,.sh,.bash,.zsh,.ps1,.groovy,.yaml,.yml,.toml,.ini " +
+		"--exclude .terraform,node_modules,vendor --include-config",
+	"docs": "--ext .md,.markdown,.rst,.org,.adoc,.txt,.tex " +
+		"--exclude node_modules,vendor,_build,site --count-unknown",
+}
+
+// presetsPath возвращает путь к файлу пользовательских наборов:
+// $XDG_CONFIG_HOME/loc_counter/presets (или аналог на macOS и Windows).
+func presetsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "loc_counter", "presets"), nil
+}
+
+// loadPresets возвращает встроенные наборы вместе с пользовательскими.
+// Файл содержит по строке «имя = аргументы»; пустые строки и строки,
+// начинающиеся с #, пропускаются. Отсутствие файла — не ошибка.
+func loadPresets() (map[string]string, error) {
+	presets := make(map[string]string, len(builtinPresets))
+	for name, args := range builtinPresets {
+		presets[name] = args
+	}
+
+	name, err := presetsPath()
+	if err != nil {
+		return presets, nil
+	}
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return presets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		preset, args, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(preset) == "" {
+			return nil, fmt.Errorf(tr("%s:%d: ожидается «имя = аргументы»"), name, n)
+		}
+		presets[strings.TrimSpace(preset)] = strings.TrimSpace(args)
+	}
+	return presets, scanner.Err()
+}
+
+// readArgsFile читает флаги из файла name (--args-file): аргументы
+// разделяются пробелами и переводами строк, пустые строки и строки,
+// начинающиеся с #, пропускаются. Кавычки не разбираются — как и в presets.
+func readArgsFile(name string) ([]string, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var args []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		args = append(args, strings.Fields(line)...)
+	}
+	return args, nil
+}
+
+// presetFlags объявляет флаги, которые допустимы в наборе: только фильтры
+// файлов. Значения попадают в те же поля opts, что и у основной команды.
+func presetFlags(fs *flag.FlagSet, opts *options) {
+	fs.Var(&opts.ext, "ext", "")
+	fs.Var(&opts.extExclude, "ext-exclude", "")
+	fs.Var(&opts.exclude, "exclude", "")
+	fs.Var(&opts.excludeFiles, "exclude-file", "")
+	fs.BoolVar(&opts.countUnknown, "count-unknown", false, "")
+	fs.BoolVar(&opts.docCode, "doc-code", false, "")
+	fs.BoolVar(&opts.includeConfig, "include-config", false, "")
+}
+
+// applyPreset добавляет к opts фильтры набора name. Списки (--ext,
+// --exclude и т. п.) дополняют заданные в командной строке.
+func applyPreset(name string, opts *options) error {
+	presets, err := loadPresets()
+	if err != nil {
+		return err
+	}
+	args, ok := presets[name]
+	if !ok {
+		names := make([]string, 0, len(presets))
+		for n := range presets {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf(tr("неизвестный набор %q (доступны: %s)"), name, strings.Join(names, ", "))
+	}
+
+	fs := flag.NewFlagSet("preset "+name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	presetFlags(fs, opts)
+	if err := fs.Parse(strings.Fields(args)); err != nil {
+		return fmt.Errorf(tr("набор %q: %v"), name, err)
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf(tr("набор %q: лишние аргументы %q"), name, fs.Args())
+	}
+	return nil
+}
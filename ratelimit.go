@@ -0,0 +1,65 @@
+package main
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// rateLimiter ограничивает суммарную скорость чтения всех файлов.
+// Накопление «кредита» за время простоя не допускается, поэтому после
+// паузы чтение не выдаёт всплеск сверх заданной скорости.
+type rateLimiter struct {
+	mu          sync.Mutex
+	bytesPerSec float64
+	next        time.Time // момент, с которого разрешено следующее чтение
+}
+
+// newRateLimiter создаёт ограничитель на mbPerSec мегабайт в секунду.
+// При mbPerSec <= 0 возвращается nil — ограничение отключено.
+func newRateLimiter(mbPerSec float64) *rateLimiter {
+	if mbPerSec <= 0 {
+		return nil
+	}
+	return &rateLimiter{bytesPerSec: mbPerSec * 1024 * 1024}
+}
+
+// wait учитывает n прочитанных байт и при необходимости приостанавливает
+// вызывающую горутину, пока скорость не опустится до допустимой.
+func (l *rateLimiter) wait(n int) {
+	if l == nil || n <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(time.Duration(float64(n) / l.bytesPerSec * float64(time.Second)))
+	l.mu.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// reader оборачивает r так, чтобы чтение из него подчинялось ограничению.
+func (l *rateLimiter) reader(r io.Reader) io.Reader {
+	if l == nil {
+		return r
+	}
+	return &limitedReader{r: r, l: l}
+}
+
+type limitedReader struct {
+	r io.Reader
+	l *rateLimiter
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	n, err := lr.r.Read(p)
+	lr.l.wait(n)
+	return n, err
+}
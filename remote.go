@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// gistPrefix — префикс цели-гиста: gist:<id>.
+const gistPrefix = "gist:"
+
+// isRemoteTarget сообщает, указывает ли цель на удалённый файл (URL)
+// или гист, а не на локальную директорию.
+func isRemoteTarget(target string) bool {
+	return strings.HasPrefix(target, gistPrefix) ||
+		strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://")
+}
+
+// scanRemote подсчитывает строки в одном удалённом файле по прямой ссылке
+// (raw URL) или во всех файлах гиста. Язык определяется по имени файла.
+func scanRemote(client *githubClient, target string, opts *options) (*report, error) {
+	if id, ok := gistID(target); ok {
+		return scanGist(client, id, opts)
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	ext := strings.ToLower(path.Ext(u.Path))
+	cfg, supported := languageByName(path.Base(u.Path))
+	if !supported {
+		return nil, fmt.Errorf(tr("%s: не удалось определить язык по имени файла %q"), target, path.Base(u.Path))
+	}
+
+	rep := opts.newReport()
+	if !opts.acceptExt(ext) {
+		return rep, nil
+	}
+
+	body, err := httpGet(target, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	r, hasher := opts.manifestReader(body)
+	counts, err := opts.lineCounter().countReader(r, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", target, err)
+	}
+	res := counts.result(displayPath(target), cfg.Name)
+	if err := hasher.finish(r, &res); err != nil {
+		return nil, fmt.Errorf("%s: %w", target, err)
+	}
+	rep.add(res)
+	return rep, nil
+}
+
+// gistID извлекает идентификатор гиста из цели вида gist:<id>
+// или https://gist.github.com/<пользователь>/<id>.
+func gistID(target string) (string, bool) {
+	if id, ok := strings.CutPrefix(target, gistPrefix); ok {
+		return id, id != ""
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Host != "gist.github.com" {
+		return "", false
+	}
+	id := path.Base(strings.TrimSuffix(u.Path, "/"))
+	return id, id != "" && id != "/" && id != "."
+}
+
+// scanGist подсчитывает строки во всех файлах гиста через GitHub API.
+func scanGist(client *githubClient, id string, opts *options) (*report, error) {
+	body, err := client.get("/gists/" + id)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var gist struct {
+		Files map[string]struct {
+			RawURL    string `json:"raw_url"`
+			Truncated bool   `json:"truncated"`
+			Content   string `json:"content"`
+		} `json:"files"`
+	}
+	if err := json.NewDecoder(body).Decode(&gist); err != nil {
+		return nil, fmt.Errorf(tr("гист %s: %w"), id, err)
+	}
+
+	names := make([]string, 0, len(gist.Files))
+	for name := range gist.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lc := opts.lineCounter()
+	rep := opts.newReport()
+	contents := make(contentIndex)
+	for _, name := range names {
+		ext := strings.ToLower(path.Ext(name))
+		cfg, supported := languageByName(path.Base(name))
+		if !supported || !opts.acceptExt(ext) {
+			continue
+		}
+
+		display := displayPath(name)
+		file := gist.Files[name]
+		var content io.ReadCloser = io.NopCloser(strings.NewReader(file.Content))
+		if file.Truncated {
+			// Содержимое больших файлов API обрезает — читаем его по raw_url
+			content, err = client.get(file.RawURL)
+			if err != nil {
+				rep.skipped = append(rep.skipped, skippedFile{display, err.Error()})
+				continue
+			}
+		}
+		r, hasher := opts.manifestReader(content)
+		counts, err := lc.countReader(r, cfg)
+		res := counts.result(display, cfg.Name)
+		if err == nil {
+			err = hasher.finish(r, &res)
+		}
+		content.Close()
+		if err != nil {
+			rep.skipped = append(rep.skipped, skippedFile{display, err.Error()})
+			continue
+		}
+		if contents.keep(res, rep, opts) {
+			rep.add(res)
+		}
+	}
+	return rep, nil
+}
@@ -0,0 +1,632 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// fileResult — результат подсчёта одного файла.
+type fileResult struct {
+	path    string
+	src     string // путь к файлу на диске без экранирования (только при обходе директории)
+	lang    string
+	lines   int
+	imports int // строки импорта (только с --imports)
+
+	comments int        // строки только с комментарием
+	blanks   int        // пустые строки
+	bytes    int        // байты содержимого (только с --bytes и --tokens)
+	chars    int        // символы содержимого (только с --bytes и --tokens)
+	tokens   int        // приблизительное число токенов (только с --bytes и --tokens)
+	todos    []todoItem // метки в комментариях (только с --todos)
+
+	// Статистика оформления (только с --style-stats)
+	longest  int    // самая длинная строка в символах
+	indent   string // стиль отступов: tabs, spaces, mixed или "" — без отступов
+	trailing int    // строки с пробелами в конце
+
+	// Повторяющийся код (только с --duplication): хеши строк до анализа,
+	// затем число строк в повторяющихся блоках и число проверенных строк
+	lineHashes  []uint64
+	duplicated  int
+	hashedLines int
+
+	goDecls []goDecl // функции, методы и типы Go-файла (только с --go-detail)
+
+	// parts — строки кода по языкам встроенных блоков; nil — весь файл
+	// на языке lang
+	parts map[string]int
+
+	buildTag string // ограничение сборки Go-файла (только с --by-build-tag)
+	owner    string // владельцы по CODEOWNERS (только с --by-owner)
+	sha256   string // SHA-256 содержимого (только с --manifest и --dedupe)
+
+	// Метаданные файла, заполняются только с --meta (размер — и с --manifest)
+	size    int64
+	modTime time.Time
+	commit  *commitInfo // nil — файл вне git или ещё не закоммичен
+}
+
+// skippedFile — файл, который не удалось посчитать, с указанием причины.
+type skippedFile struct {
+	path   string
+	reason string
+}
+
+// report — результат обхода директории.
+type report struct {
+	root         string // корень обхода; пусто — файлы не с диска (архив, API)
+	files        []fileResult
+	skipped      []skippedFile
+	totalLines   int
+	totalImports int
+	// totalComments и totalBlanks — строки комментариев и пустые строки
+	totalComments, totalBlanks int
+	duplicates                 int  // повторные жёсткие ссылки, не учтённые в подсчёте
+	incomplete                 bool // обход прерван сигналом
+
+	// stream получает каждый посчитанный файл сразу (--format ndjson);
+	// такие файлы не хранятся в files, а только учитываются в итогах
+	stream   func(fileResult)
+	streamed int // число файлов, переданных в stream
+
+	submodules      map[string]*subtotal // подмодуль -> итог
+	repos           map[string]*subtotal // репозиторий из --repos -> итог
+	unknown         map[string]int       // нераспознанное расширение -> число файлов
+	buildTags       map[string]*subtotal // ограничение сборки Go -> итог ("" — без ограничений)
+	cgo             subtotal             // строки C в преамбулах cgo (только с --cgo)
+	owners          map[string]*subtotal // владельцы по CODEOWNERS -> итог ("" — без владельца)
+	modules         map[string]*subtotal // корень модуля -> итог ("" — вне модулей)
+	moduleManifests map[string]string    // корень модуля -> файл-манифест
+	todos           map[string]int       // метка в комментариях -> число (только с --todos)
+	config          map[string]*subtotal // язык конфигурации -> итог (только с --include-config)
+
+	// contentDuplicates — побайтные копии ранее найденных файлов (только с --dedupe)
+	contentDuplicates []contentDuplicate
+}
+
+func newReport() *report {
+	return &report{
+		submodules:      make(map[string]*subtotal),
+		repos:           make(map[string]*subtotal),
+		unknown:         make(map[string]int),
+		buildTags:       make(map[string]*subtotal),
+		owners:          make(map[string]*subtotal),
+		modules:         make(map[string]*subtotal),
+		moduleManifests: make(map[string]string),
+		todos:           make(map[string]int),
+		config:          make(map[string]*subtotal),
+	}
+}
+
+// add добавляет результат файла в отчёт и обновляет итоги.
+func (r *report) add(res fileResult) {
+	if isConfigLanguage(res.lang) {
+		// Конфигурация не входит в итоги кода
+		r.addConfig(res)
+		return
+	}
+	if r.stream != nil {
+		r.stream(res)
+		r.streamed++
+	} else {
+		r.files = append(r.files, res)
+	}
+	r.totalLines += res.lines
+	r.totalImports += res.imports
+	r.totalComments += res.comments
+	r.totalBlanks += res.blanks
+	for _, t := range res.todos {
+		r.todos[t.Marker]++
+	}
+	r.addSubtotal(r.owners, res.owner, res.lines)
+	if res.lang == "Go" {
+		r.addSubtotal(r.buildTags, res.buildTag, res.lines)
+		if c := res.parts["C"]; c > 0 {
+			r.cgo.add(c)
+		}
+	}
+}
+
+// addUnknown учитывает файл с нераспознанным расширением. Файлы без
+// расширения и отброшенные фильтрами --ext/--ext-exclude не учитываются.
+func (r *report) addUnknown(ext string, opts *options) {
+	if ext != "" && opts.acceptExt(ext) {
+		r.unknown[ext]++
+	}
+}
+
+func (r *report) addSubtotal(totals map[string]*subtotal, key string, lines int) {
+	if totals[key] == nil {
+		totals[key] = &subtotal{}
+	}
+	totals[key].add(lines)
+}
+
+// byLanguage возвращает итоги по языкам, отсортированные по убыванию
+// числа строк.
+func (r *report) byLanguage() ([]string, map[string]*subtotal) {
+	totals := make(map[string]*subtotal)
+	for _, f := range r.files {
+		if f.parts != nil {
+			for lang, lines := range f.parts {
+				r.addSubtotal(totals, lang, lines)
+			}
+			// Комментарии, пустые строки и импорты по частям не делятся —
+			// они относятся к языку файла, чтобы столбцы сходились с итогом
+			if totals[f.lang] == nil {
+				totals[f.lang] = &subtotal{}
+			}
+		} else {
+			r.addSubtotal(totals, f.lang, f.lines)
+		}
+		totals[f.lang].imports += f.imports
+		totals[f.lang].comments += f.comments
+		totals[f.lang].blanks += f.blanks
+	}
+
+	langs := make([]string, 0, len(totals))
+	for lang := range totals {
+		langs = append(langs, lang)
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		if totals[langs[i]].lines != totals[langs[j]].lines {
+			return totals[langs[i]].lines > totals[langs[j]].lines
+		}
+		return langs[i] < langs[j]
+	})
+	return langs, totals
+}
+
+// exitOverLimit — код завершения с --fail-over, если лимит строк превышен.
+const exitOverLimit = 3
+
+// overBudget возвращает файлы, в которых строк больше maxLines.
+func (r *report) overBudget(maxLines int) []fileResult {
+	if maxLines <= 0 {
+		return nil
+	}
+	var over []fileResult
+	for _, f := range r.files {
+		if f.lines > maxLines {
+			over = append(over, f)
+		}
+	}
+	return over
+}
+
+// printSkipped выводит в stderr сводку пропущенных файлов. Она печатается
+// в конце работы, чтобы не теряться среди строк таблицы.
+func (r *report) printSkipped() {
+	if len(r.skipped) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, tr("Пропущено файлов: %d\n"), len(r.skipped))
+	for _, s := range r.skipped {
+		fmt.Fprintf(os.Stderr, "  %s: %s\n", s.path, s.reason)
+	}
+}
+
+// printText выводит отчёт в виде выровненной таблицы.
+func printText(rep *report, opts *options) {
+	if len(rep.files) == 0 {
+		fmt.Println(tr("Поддерживаемые исходные файлы не найдены."))
+		if rep.incomplete {
+			fmt.Println(tr("ОТЧЁТ НЕПОЛНЫЙ: подсчёт прерван, учтены не все файлы."))
+		}
+		if opts.includeConfig {
+			// В дереве может быть одна конфигурация — её сводка всё равно нужна
+			fmt.Println()
+			printConfig(rep)
+		}
+		if opts.unknown && len(rep.unknown) > 0 {
+			fmt.Println()
+			printUnknown(rep)
+		}
+		return
+	}
+
+	switch {
+	case len(opts.columns) > 0 || opts.breakdown || opts.density || opts.bytes || opts.tokens || opts.todos || opts.styleStats || opts.duplication || tableStyle != "plain":
+		printFileColumns(rep, opts)
+	case opts.meta || opts.imports:
+		printColumnsTable(rep, opts)
+	default:
+		printFileTable(rep, opts)
+	}
+	if shown := len(opts.shownFiles(rep)); shown < len(rep.files) {
+		fmt.Printf(tr("Показаны %d крупнейших файлов из %d\n"), shown, len(rep.files))
+	}
+	if rep.duplicates > 0 {
+		fmt.Printf(tr("Повторные жёсткие ссылки не учтены: %d\n"), rep.duplicates)
+	}
+	if opts.cgo && rep.cgo.files > 0 {
+		fmt.Printf(tr("Строк C в преамбулах cgo: %d (файлов: %d)\n"), rep.cgo.lines, rep.cgo.files)
+	}
+	if len(opts.weights) > 0 {
+		fmt.Printf(tr("Нормированный итог: %.1f\n"), opts.weights.normalized(rep))
+	}
+	if over := rep.overBudget(opts.maxLines); len(over) > 0 {
+		fmt.Printf(tr("Файлов сверх лимита %d строк: %d\n"), opts.maxLines, len(over))
+	}
+	if rep.incomplete {
+		fmt.Println(tr("ОТЧЁТ НЕПОЛНЫЙ: подсчёт прерван, учтены не все файлы."))
+	}
+
+	fmt.Println()
+
+	if opts.byLang {
+		printLanguages(rep, opts)
+	}
+	if opts.chart {
+		printTerminalChart(rep.languageBars(), rep.totalLines)
+	}
+	if opts.stats {
+		printStats(rep, opts.statsLarge)
+	}
+	if opts.goDetail {
+		printGoDetail(rep, opts.goFuncLimit)
+	}
+	if opts.duplication {
+		printDuplication(rep, opts.duplicationMin)
+	}
+	if opts.dedupe {
+		printContentDuplicates(rep, opts)
+	}
+	if opts.includeConfig {
+		printConfig(rep)
+	}
+	if opts.todos {
+		printTodos(rep, opts)
+	}
+	if opts.cocomo {
+		printCocomo(rep, opts.cocomoParams)
+	}
+	if opts.byExt {
+		printTable(extensionTable(rep, opts))
+	}
+	if opts.byDir > 0 {
+		printSubtotals(tr("Директория"), rep.byDir(int(opts.byDir)), nil)
+	}
+	if opts.tree {
+		printTree(rep)
+	}
+
+	// Промежуточные итоги по репозиториям, подмодулям и модулям
+	if len(rep.repos) > 0 {
+		printSubtotals(tr("Репозиторий"), rep.repos, nil)
+	}
+	if len(rep.submodules) > 0 {
+		printSubtotals(tr("Подмодуль"), rep.submodules, nil)
+	}
+	if opts.byModule {
+		printSubtotals(tr("Модуль"), rep.modules, func(mod string) string {
+			if mod == "" {
+				return tr("(вне модулей)")
+			}
+			if m, ok := rep.moduleManifests[mod]; ok {
+				return fmt.Sprintf("%s (%s)", mod, m)
+			}
+			return mod
+		})
+	}
+	if opts.byOwner {
+		printSubtotals(tr("Владелец"), rep.owners, func(owner string) string {
+			if owner == "" {
+				return tr("(без владельца)")
+			}
+			return owner
+		})
+	}
+	if opts.byBuildTag && len(rep.buildTags) > 0 {
+		printSubtotals(tr("Ограничение сборки Go"), rep.buildTags, func(tag string) string {
+			if tag == "" {
+				return tr("(без ограничений)")
+			}
+			return tag
+		})
+	}
+	if opts.coverage != nil {
+		printCoverage(rep, opts.coverage)
+	}
+	if opts.unknown {
+		printUnknown(rep)
+	}
+}
+
+// shownFiles возвращает файлы для таблицы: все или, с --top N, N файлов
+// с наибольшим числом строк. Итоги таблиц всегда считаются по всем файлам.
+func (o *options) shownFiles(rep *report) []fileResult {
+	if o.top <= 0 || o.top >= len(rep.files) {
+		return rep.files
+	}
+	files := append([]fileResult(nil), rep.files...)
+	sort.SliceStable(files, func(i, j int) bool { return files[i].lines > files[j].lines })
+	return files[:o.top]
+}
+
+// printFileTable выводит таблицу «файл — строки» с итоговой строкой.
+// В цветном выводе пути окрашены по языку, а файлы сверх --max-lines — красным.
+func printFileTable(rep *report, opts *options) {
+	// Ширина считается в символах, а не в байтах: иначе имена
+	// с кириллицей сдвигают столбец
+	maxPathLen := 0
+	for _, r := range opts.shownFiles(rep) {
+		maxPathLen = max(maxPathLen, utf8.RuneCountInString(r.path))
+	}
+
+	pad := func(s string) string {
+		return s + strings.Repeat(" ", max(maxPathLen-utf8.RuneCountInString(s), 0))
+	}
+
+	fmt.Println()
+	fmt.Printf("%s  %s\n", pad(tr("Файл")), tr("Строки"))
+	fmt.Println(strings.Repeat("-", maxPathLen+10))
+	for _, r := range opts.shownFiles(rep) {
+		if opts.maxLines > 0 && r.lines > opts.maxLines {
+			fmt.Println(paint(ansiRed, fmt.Sprintf("%s  %d", pad(r.path), r.lines)))
+			continue
+		}
+		fmt.Printf("%s  %d\n", paint(languageColor(r.lang), pad(r.path)), r.lines)
+	}
+	fmt.Println(strings.Repeat("-", maxPathLen+10))
+	fmt.Println(paint(ansiBold, fmt.Sprintf("%s  %d", pad(fmt.Sprintf(tr("Итого (%d файлов)"), len(rep.files))), rep.totalLines)))
+}
+
+// printColumnsTable выводит таблицу файлов с дополнительными столбцами:
+// строками импорта (--imports) и метаданными (--meta) — размером, временем
+// изменения и последним коммитом. Столбцы выравниваются по левому краю,
+// как в обычной таблице файлов.
+func printColumnsTable(rep *report, opts *options) {
+	headers := []string{tr("Файл"), tr("Строки")}
+	if opts.imports {
+		headers = append(headers, tr("Импорты"))
+	}
+	if opts.meta {
+		headers = append(headers, tr("Байт"), tr("Изменён"), tr("Автор"), tr("Коммит"))
+	}
+
+	rows := make([][]string, 0, len(rep.files))
+	for _, f := range opts.shownFiles(rep) {
+		row := []string{f.path, strconv.Itoa(f.lines)}
+		if opts.imports {
+			row = append(row, strconv.Itoa(f.imports))
+		}
+		if opts.meta {
+			author, date := "-", "-"
+			if f.commit != nil {
+				author, date = f.commit.author, f.commit.date
+			}
+			row = append(row,
+				strconv.FormatInt(f.size, 10),
+				f.modTime.Format("2006-01-02 15:04"),
+				author,
+				date,
+			)
+		}
+		if opts.maxLines > 0 && f.lines > opts.maxLines {
+			row = paintRow(ansiRed, row)
+		} else {
+			row[0] = paint(languageColor(f.lang), row[0])
+		}
+		rows = append(rows, row)
+	}
+	var totalSize int64
+	for _, f := range rep.files {
+		totalSize += f.size
+	}
+
+	totals := []string{fmt.Sprintf(tr("Итого (%d файлов)"), len(rep.files)), strconv.Itoa(rep.totalLines)}
+	if opts.imports {
+		totals = append(totals, strconv.Itoa(rep.totalImports))
+	}
+	if opts.meta {
+		totals = append(totals, strconv.FormatInt(totalSize, 10))
+	}
+
+	widths := make([]int, len(headers))
+	for _, row := range append(rows, headers, totals) {
+		for i, cell := range row {
+			widths[i] = max(widths[i], visibleLen(cell))
+		}
+	}
+	lineWidth := len(widths) * 2
+	for _, w := range widths {
+		lineWidth += w
+	}
+
+	printRow := func(row []string) {
+		var sb strings.Builder
+		for i, cell := range row {
+			if i > 0 {
+				sb.WriteString("  ")
+			}
+			sb.WriteString(cell)
+			if i < len(row)-1 {
+				sb.WriteString(strings.Repeat(" ", max(widths[i]-visibleLen(cell), 0)))
+			}
+		}
+		fmt.Println(sb.String())
+	}
+
+	fmt.Println()
+	printRow(headers)
+	fmt.Println(strings.Repeat("-", lineWidth))
+	for _, row := range rows {
+		printRow(row)
+	}
+	fmt.Println(strings.Repeat("-", lineWidth))
+	printRow(paintRow(ansiBold, totals))
+}
+
+// printLanguages выводит сводку по языкам: число файлов, строк
+// и среднее число строк на файл. С --imports добавляется столбец строк
+// импорта, а если заданы веса языков — столбцы веса и нормированного
+// числа строк.
+func printLanguages(rep *report, opts *options) {
+	headers, rows, total := languageTable(rep, opts)
+	for _, row := range rows {
+		row[0] = paint(languageColor(row[0]), row[0])
+	}
+	printTable(headers, rows, total)
+	printIDL(rep)
+}
+
+// languageTable возвращает заголовки, строки и итог сводки по языкам.
+func languageTable(rep *report, opts *options) (headers []string, rows [][]string, total []string) {
+	langs, totals := rep.byLanguage()
+	return summaryTable(tr("Язык"), langs, totals, rep, opts)
+}
+
+// extensionTable возвращает заголовки, строки и итог сводки по расширениям.
+func extensionTable(rep *report, opts *options) (headers []string, rows [][]string, total []string) {
+	exts, totals := rep.byExtension()
+	sort.Slice(exts, func(i, j int) bool {
+		if totals[exts[i]].lines != totals[exts[j]].lines {
+			return totals[exts[i]].lines > totals[exts[j]].lines
+		}
+		return exts[i] < exts[j]
+	})
+	for i, ext := range exts {
+		if ext == "" {
+			exts[i] = tr("(без расширения)")
+			totals[exts[i]] = totals[ext]
+		}
+	}
+	return summaryTable(tr("Расширение"), exts, totals, rep, opts)
+}
+
+// summaryTable возвращает сводку по группам файлов names: число файлов,
+// строк, долю от общего итога и среднее на файл. С --imports добавляется
+// столбец строк импорта, а с весами языков — столбцы веса и нормированного
+// числа строк.
+func summaryTable(title string, names []string, totals map[string]*subtotal, rep *report, opts *options) (headers []string, rows [][]string, total []string) {
+	weights := opts.weights
+	headers = []string{title, tr("Файлы"), tr("Строки"), tr("Доля"), tr("Среднее")}
+	if opts.breakdown {
+		headers = append(headers, tr("Комментарии"), tr("Пустые"))
+	}
+	if opts.density {
+		headers = append(headers, tr("Плотность"))
+	}
+	if opts.imports {
+		headers = append(headers, tr("Импорты"))
+	}
+	if len(weights) > 0 {
+		headers = append(headers, tr("Вес"), tr("Нормировано"))
+	}
+
+	rows = make([][]string, 0, len(names))
+	for _, name := range names {
+		t := totals[name]
+		row := []string{name, strconv.Itoa(t.files), strconv.Itoa(t.lines), formatShare(t.lines, rep.totalLines), formatAverage(t.lines, t.files)}
+		if opts.breakdown {
+			row = append(row, strconv.Itoa(t.comments), strconv.Itoa(t.blanks))
+		}
+		if opts.density {
+			row = append(row, formatShare(t.comments, t.lines+t.comments))
+		}
+		if opts.imports {
+			row = append(row, strconv.Itoa(t.imports))
+		}
+		if len(weights) > 0 {
+			w := weights.weight(name)
+			row = append(row, strconv.FormatFloat(w, 'g', -1, 64), strconv.FormatFloat(float64(t.lines)*w, 'f', 1, 64))
+		}
+		rows = append(rows, row)
+	}
+
+	total = []string{tr("Итого"), strconv.Itoa(len(rep.files)), strconv.Itoa(rep.totalLines), formatShare(rep.totalLines, rep.totalLines), formatAverage(rep.totalLines, len(rep.files))}
+	if opts.breakdown {
+		total = append(total, strconv.Itoa(rep.totalComments), strconv.Itoa(rep.totalBlanks))
+	}
+	if opts.density {
+		total = append(total, formatShare(rep.totalComments, rep.totalLines+rep.totalComments))
+	}
+	if opts.imports {
+		total = append(total, strconv.Itoa(rep.totalImports))
+	}
+	if len(weights) > 0 {
+		total = append(total, "", strconv.FormatFloat(weights.normalized(rep), 'f', 1, 64))
+	}
+	return headers, rows, total
+}
+
+// printUnknown выводит нераспознанные расширения по убыванию числа файлов
+// с подсказкой, как начать их учитывать.
+func printUnknown(rep *report) {
+	if len(rep.unknown) == 0 {
+		return
+	}
+	exts := make([]string, 0, len(rep.unknown))
+	for ext := range rep.unknown {
+		exts = append(exts, ext)
+	}
+	sort.Slice(exts, func(i, j int) bool {
+		if rep.unknown[exts[i]] != rep.unknown[exts[j]] {
+			return rep.unknown[exts[i]] > rep.unknown[exts[j]]
+		}
+		return exts[i] < exts[j]
+	})
+
+	rows := make([][]string, 0, len(exts))
+	for _, ext := range exts {
+		rows = append(rows, []string{ext, strconv.Itoa(rep.unknown[ext])})
+	}
+	printTable([]string{tr("Нераспознанное расширение"), tr("Файлы")}, rows, nil)
+	fmt.Println(tr("Чтобы учитывать их, сопоставьте расширение языку (например, --lang-priority .jsm=JavaScript)"))
+	fmt.Println(tr("или добавьте язык в knownLanguages (см. README); --count-unknown учтёт текстовые файлы как Other."))
+	fmt.Println()
+}
+
+// formatAverage возвращает среднее число строк на файл с одним знаком
+// после запятой.
+func formatAverage(lines, files int) string {
+	if files == 0 {
+		return "0.0"
+	}
+	return strconv.FormatFloat(float64(lines)/float64(files), 'f', 1, 64)
+}
+
+// printQuickfix выводит файлы сверх лимита строк в формате quickfix
+// (`путь:1: сообщение`), который понимают Vim (:cfile) и Emacs (compilation-mode).
+func printQuickfix(rep *report, opts *options) {
+	for _, f := range rep.overBudget(opts.maxLines) {
+		fmt.Printf(tr("%s:1: %d строк (превышение лимита %d)\n"), f.path, f.lines, opts.maxLines)
+	}
+}
+
+// printSubtotals выводит таблицу промежуточных итогов, отсортированную
+// по имени группы. label задаёт подпись группы (nil — само имя).
+func printSubtotals(title string, totals map[string]*subtotal, label func(string) string) {
+	names := make([]string, 0, len(totals))
+	for name := range totals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rows := make([][]string, 0, len(names))
+	for _, name := range names {
+		t := totals[name]
+		if label != nil {
+			name = label(name)
+		}
+		rows = append(rows, []string{name, strconv.Itoa(t.files), strconv.Itoa(t.lines)})
+	}
+	printTable([]string{title, tr("Файлы"), tr("Строки")}, rows, nil)
+}
+
+// writeOutput выполняет print, направив stdout в файл f, и закрывает его.
+// Так любой формат вывода можно сохранить в файл без изменения печати.
+func writeOutput(f *os.File, print func()) error {
+	stdout := os.Stdout
+	os.Stdout = f
+	print()
+	os.Stdout = stdout
+	return f.Close()
+}
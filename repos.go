@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// readRepoList читает список репозиториев для --repos: по пути или URL
+// на строку, пустые строки и строки с # пропускаются.
+func readRepoList(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var repos []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		repos = append(repos, line)
+	}
+	return repos, scanner.Err()
+}
+
+// isRemoteRepo сообщает, задан ли репозиторий адресом для git clone,
+// а не локальным путём.
+func isRemoteRepo(repo string) bool {
+	return strings.Contains(repo, "://") || strings.HasPrefix(repo, "git@")
+}
+
+// repoName — короткое имя удалённого репозитория для путей отчёта:
+// последний компонент адреса без .git.
+func repoName(repo string) string {
+	repo = strings.TrimSuffix(strings.TrimSuffix(repo, "/"), ".git")
+	if i := strings.LastIndexAny(repo, "/:"); i >= 0 {
+		repo = repo[i+1:]
+	}
+	return repo
+}
+
+// scanRepo подсчитывает один репозиторий из списка --repos. Удалённый
+// репозиторий клонируется без истории во временную директорию, и пути
+// в отчёте начинаются с его имени, а не с временного пути.
+func scanRepo(repo string, opts *options) (*report, error) {
+	if !isRemoteRepo(repo) {
+		return scan(repo, opts)
+	}
+
+	tmp, err := os.MkdirTemp("", "loc-counter-repo-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	if _, err := runGit(tmp, "clone", "--quiet", "--depth", "1", repo, "."); err != nil {
+		return nil, err
+	}
+	rep, err := scan(tmp, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := displayPath(tmp) + "/"
+	name := repoName(repo)
+	for i := range rep.files {
+		rep.files[i].path = path.Join(name, strings.TrimPrefix(rep.files[i].path, prefix))
+	}
+	for i := range rep.skipped {
+		rep.skipped[i].path = path.Join(name, strings.TrimPrefix(rep.skipped[i].path, prefix))
+	}
+	return rep, nil
+}
+
+// scanRepos параллельно подсчитывает все репозитории списка функцией
+// scanOne и сводит результаты в один отчёт с промежуточными итогами
+// по репозиториям. Репозиторий, который не удалось посчитать, попадает
+// в пропущенные.
+func scanRepos(repos []string, scanOne func(repo string) (*report, error)) *report {
+	reports := make([]*report, len(repos))
+	errs := make([]error, len(repos))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.NumCPU(), len(repos)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				reports[i], errs[i] = scanOne(repos[i])
+			}
+		}()
+	}
+	for i := range repos {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Сводим в порядке списка, чтобы отчёт не зависел от порядка завершения
+	rep := newReport()
+	for i, repo := range repos {
+		if errs[i] != nil {
+			rep.skipped = append(rep.skipped, skippedFile{repo, errs[i].Error()})
+			continue
+		}
+		r := reports[i]
+		rep.repos[repo] = &subtotal{}
+		for _, f := range r.files {
+			rep.add(f)
+			rep.repos[repo].add(f.lines)
+		}
+		rep.skipped = append(rep.skipped, r.skipped...)
+		rep.duplicates += r.duplicates
+		for ext, n := range r.unknown {
+			rep.unknown[ext] += n
+		}
+		// Файлы конфигурации не входят в r.files — их итоги сводятся отдельно
+		for lang, t := range r.config {
+			sum := rep.config[lang]
+			if sum == nil {
+				sum = &subtotal{}
+				rep.config[lang] = sum
+			}
+			sum.files += t.files
+			sum.lines += t.lines
+			sum.comments += t.comments
+			sum.blanks += t.blanks
+		}
+		rep.incomplete = rep.incomplete || r.incomplete
+		for name, t := range r.submodules {
+			rep.submodules[path.Join(repo, name)] = t
+		}
+		for name, t := range r.modules {
+			if name == "" {
+				rep.modules[repo+" "+tr("(вне модулей)")] = t
+				continue
+			}
+			rep.modules[path.Join(repo, name)] = t
+		}
+		for name, m := range r.moduleManifests {
+			rep.moduleManifests[path.Join(repo, name)] = m
+		}
+	}
+	return rep
+}
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// options — параметры подсчёта, заданные флагами командной строки.
+type options struct {
+	exclude        dirStringSlice
+	ext            extStringSlice
+	extExclude     extStringSlice
+	fileTimeout    time.Duration
+	countHardlinks bool
+	checkpoint     string
+	resume         bool
+	ioLimit        float64
+	nice           bool
+	patch          string
+	tracked        bool
+	submodules     string
+	byModule       bool
+	noPager        bool
+	formats        formatList
+	maxLines       int
+	maxAdded       int
+	ignoreSpace    bool
+	failOver       bool
+	chartOut       string
+	meta           bool
+	byLang         bool
+	weights        weightTable
+	match          *regexp.Regexp
+	ignore         *regexp.Regexp
+	imports        bool
+	image          string
+	github         string
+	token          string
+	githubAPI      string
+	repos          string
+	priority       languagePriority
+	unknown        bool
+	docCode        bool
+	includeConfig  bool
+	asmSyntax      string
+	byBuildTag     bool
+	cgo            bool
+	byOwner        bool
+	coverage       coverageProfile
+	countUnknown   bool
+	manifest       string
+	excludeFiles   patternStringSlice
+	noHeader       bool
+	csvDelimiter   string
+	csvQuote       string
+	out            string
+	compat         string
+	template       string
+	color          string
+	columns        columnList
+	style          string
+	tree           bool
+	lang           string
+	byExt          bool
+	breakdown      bool
+	density        bool
+	byDir          dirDepth
+	top            int
+	chart          bool
+	cocomo         bool
+	cocomoParams   cocomoOptions
+	bytes          bool
+	stats          bool
+	statsLarge     int
+	tokens         bool
+	todos          bool
+	todoExtra      []string // метки --todo-markers
+	todosList      bool
+	styleStats     bool
+	dedupe         bool
+	dedupeExclude  bool
+	duplication    bool
+	duplicationMin int
+	goDetail       bool
+	goFuncLimit    int
+	backend        string
+	detect         string
+
+	// stream — получатель файлов при потоковом выводе (--format ndjson)
+	stream func(fileResult)
+}
+
+// acceptExt сообщает, проходит ли расширение фильтры --ext и --ext-exclude.
+// --ext-exclude имеет приоритет над --ext.
+func (o *options) acceptExt(ext string) bool {
+	for _, e := range o.extExclude {
+		if e == ext {
+			return false
+		}
+	}
+	if len(o.ext) == 0 {
+		return true
+	}
+	for _, e := range o.ext {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// excludedDir сообщает, исключена ли директория флагом --exclude:
+// по имени (на любой глубине) или по пути относительно корня обхода.
+func (o *options) excludedDir(name, relPath string) bool {
+	dirName := normalizeDir(name)
+	for _, excluded := range o.exclude {
+		excluded = strings.TrimSuffix(excluded, "/")
+		if dirName == normalizeDir(excluded) {
+			return true
+		}
+		if relPath != "" && (relPath == excluded || strings.HasPrefix(relPath+"/", excluded+"/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// newReport создаёт отчёт; при потоковом выводе файлы передаются
+// в opts.stream по мере подсчёта, а не накапливаются.
+func (o *options) newReport() *report {
+	r := newReport()
+	r.stream = o.stream
+	return r
+}
+
+// excludedFile сообщает, исключён ли файл (путь со слешами относительно
+// корня) флагом --exclude-file: шаблон без слеша сравнивается с именем
+// файла, шаблон со слешем — с путём целиком.
+func (o *options) excludedFile(relPath string) bool {
+	for _, pattern := range o.excludeFiles {
+		name := path.Base(relPath)
+		if strings.Contains(pattern, "/") {
+			name = relPath
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// lineCounter создаёт счётчик строк с ограничениями и фильтрами из флагов.
+func (o *options) lineCounter() *lineCounter {
+	return &lineCounter{
+		limiter: newRateLimiter(o.ioLimit),
+		timeout: o.fileTimeout,
+		match:   o.match,
+		ignore:  o.ignore,
+		imports: o.imports,
+		cgo:     o.cgo,
+		text:    o.bytes || o.tokens,
+		todos:   o.todoPattern(),
+		style:   o.styleStats,
+		hashes:  o.duplication,
+		goDecls: o.goDetail,
+		exact:   o.backend == "exact",
+	}
+}
+
+// excludedPath сообщает, лежит ли файл (путь со слешами относительно корня)
+// в директории, исключённой флагом --exclude. Используется там, где нет
+// обхода директорий, — например, при чтении архивов.
+func (o *options) excludedPath(relPath string) bool {
+	for dir := path.Dir(relPath); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if o.excludedDir(path.Base(dir), dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// scan обходит директорию dir и подсчитывает строки кода в поддерживаемых
+// файлах. Ошибки отдельных файлов не прерывают обход — такие файлы попадают
+// в report.skipped. При SIGINT/SIGTERM обход останавливается и возвращается
+// частичный отчёт с report.incomplete.
+func scan(dir string, opts *options) (*report, error) {
+	rep := opts.newReport()
+	rep.root = dir
+	lc := opts.lineCounter()
+
+	// Контрольная точка: результаты прошлого запуска используются без повторного чтения
+	var cp *checkpoint
+	var resumed map[string]fileCounts
+	if opts.checkpoint != "" {
+		var err error
+		if cp, resumed, err = openCheckpoint(opts.checkpoint, opts.resume, opts.countingOptions()); err != nil {
+			return nil, fmt.Errorf(tr("открытие контрольной точки: %w"), err)
+		}
+	}
+
+	// Только файлы под контролем версий
+	var tracked *trackedSet
+	if opts.tracked {
+		var err error
+		if tracked, err = trackedFiles(dir); err != nil {
+			return nil, fmt.Errorf("--tracked: %w", err)
+		}
+	}
+
+	subs := submodulePaths(dir)
+
+	var owners []ownerRule
+	if opts.byOwner {
+		var name string
+		var err error
+		if owners, name, err = loadCodeowners(dir); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if name == "" {
+			fmt.Fprintln(os.Stderr, tr("предупреждение: --by-owner: файл CODEOWNERS не найден, все файлы без владельца"))
+		}
+	}
+
+	var commits map[string]commitInfo
+	if opts.meta {
+		commits = lastCommits(dir)
+	}
+
+	// Уже посчитанные файлы — чтобы жёсткие ссылки и bind mount
+	// не приводили к повторному подсчёту
+	seen := make(map[fileID]bool)
+	// Хеши содержимого для --dedupe
+	contents := make(contentIndex)
+
+	interrupted := watchInterrupt()
+
+	// На Windows обходим дерево по пути в расширенной форме (\\?\...),
+	// чтобы глубоко вложенные файлы не упирались в ограничение MAX_PATH
+	root := longPath(dir)
+
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		// Путь для вывода строится от исходного аргумента, а не от расширенной формы
+		name := path
+		if root != dir {
+			if rel, relErr := filepath.Rel(root, path); relErr == nil {
+				name = filepath.Join(dir, rel)
+			}
+		}
+		src := name
+		name = displayPath(name)
+
+		if interrupted.Load() {
+			return filepath.SkipAll
+		}
+
+		if err != nil {
+			rep.skipped = append(rep.skipped, skippedFile{name, err.Error()})
+			return nil
+		}
+
+		// Путь относительно корня обхода в форме со слешами — так же,
+		// как его выводит git
+		relPath, relErr := filepath.Rel(root, path)
+		relPath = filepath.ToSlash(relPath)
+		if relErr != nil {
+			relPath = ""
+		}
+
+		if d.IsDir() {
+			// Директории без отслеживаемых файлов не обходим вовсе
+			if tracked != nil && relPath != "" && !tracked.dirs[relPath] {
+				return filepath.SkipDir
+			}
+
+			if opts.submodules == "skip" && subs[relPath] {
+				return filepath.SkipDir
+			}
+
+			if opts.byModule && relPath != "" {
+				if m := moduleManifest(path); m != "" {
+					rep.moduleManifests[relPath] = m
+				}
+			}
+
+			// Проверяем, нужно ли пропустить эту директорию
+			if opts.excludedDir(filepath.Base(path), relPath) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if tracked != nil && !tracked.files[relPath] {
+			return nil
+		}
+
+		if opts.excludedFile(relPath) {
+			return nil
+		}
+
+		submodule := submoduleOf(relPath, subs)
+		if opts.submodules == "only" && submodule == "" {
+			return nil
+		}
+
+		ext := strings.ToLower(filepath.Ext(path))
+		cfg, supported := opts.language(filepath.Base(path), func() []byte { return fileHead(path) })
+		if !supported {
+			rep.addUnknown(ext, opts)
+			return nil
+		}
+
+		// Применяем фильтры
+		if !opts.acceptExt(ext) {
+			return nil
+		}
+
+		if !opts.countHardlinks {
+			if info, infoErr := d.Info(); infoErr == nil {
+				if id, ok := fileIdentity(path, info); ok {
+					if seen[id] {
+						rep.duplicates++
+						return nil
+					}
+					seen[id] = true
+				}
+			}
+		}
+
+		// Файлы из контрольной точки не читаются, их хеш считается отдельно
+		hasher := opts.contentHasher()
+		counts, ok := resumed[name]
+		if !ok {
+			counts, err = lc.countFile(path, cfg, hasher)
+			if err != nil {
+				rep.skipped = append(rep.skipped, skippedFile{name, err.Error()})
+				return nil
+			}
+			if cp != nil {
+				cp.add(name, counts)
+			}
+		}
+		lines := counts.Code
+
+		res := counts.result(name, cfg.Name)
+		res.src = src
+		if opts.meta {
+			if info, infoErr := d.Info(); infoErr == nil {
+				res.size = info.Size()
+				res.modTime = info.ModTime()
+			}
+			if c, ok := commits[relPath]; ok {
+				res.commit = &c
+			}
+		}
+		if opts.byBuildTag && cfg.Name == "Go" {
+			res.buildTag = goBuildConstraint(name, fileHead(path))
+		}
+		if opts.byOwner {
+			res.owner = ownerOf(relPath, owners)
+		}
+		if hasher != nil && ok {
+			if err := hashFile(path, &res); err != nil {
+				rep.skipped = append(rep.skipped, skippedFile{name, err.Error()})
+				return nil
+			}
+		} else {
+			hasher.record(&res)
+		}
+		if !contents.keep(res, rep, opts) {
+			return nil
+		}
+		rep.add(res)
+		if submodule != "" {
+			rep.addSubtotal(rep.submodules, submodule, lines)
+		}
+		if opts.byModule {
+			rep.addSubtotal(rep.modules, moduleOf(relPath, rep.moduleManifests), lines)
+		}
+		return nil
+	})
+
+	if err != nil {
+		if cp != nil {
+			cp.close()
+		}
+		return nil, fmt.Errorf(tr("обход директории: %w"), err)
+	}
+
+	// При прерывании контрольная точка сохраняется, чтобы можно было продолжить
+	rep.incomplete = interrupted.Load()
+	if cp != nil {
+		var cpErr error
+		if rep.incomplete {
+			cpErr = cp.close()
+		} else {
+			cpErr = cp.finish()
+		}
+		if cpErr != nil {
+			fmt.Fprintf(os.Stderr, tr("предупреждение: ошибка записи контрольной точки: %v\n"), cpErr)
+		}
+	}
+
+	return rep, nil
+}
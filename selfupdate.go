@@ -0,0 +1,236 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// version — версия сборки; в релизах задаётся через
+// -ldflags "-X main.version=v1.2.3".
+var version = "dev"
+
+// releaseRepo — репозиторий GitHub, из релизов которого обновляется утилита.
+const releaseRepo = "alex6712/loc-counter"
+
+// checksumsAsset — файл релиза с контрольными суммами SHA-256 архивов
+// в формате sha256sum.
+const checksumsAsset = "checksums.txt"
+
+const selfUpdateSynopsis = "loc_counter self-update [--check] [--force]"
+
+// selfUpdateFlags объявляет флаги подкоманды self-update.
+func selfUpdateFlags(fs *flag.FlagSet) (check, force *bool, api *string) {
+	check = fs.Bool("check", false, "Только проверить, есть ли новая версия, не обновляя.")
+	force = fs.Bool("force", false, "Переустановить, даже если версия совпадает с последней.")
+	api = fs.String("api", defaultGitHubAPI, "Адрес GitHub API.")
+	return check, force, api
+}
+
+// release — последний релиз в ответе GitHub API.
+type release struct {
+	Tag    string `json:"tag_name"`
+	Assets []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// asset возвращает адрес файла релиза по имени.
+func (r *release) asset(name string) (string, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a.URL, true
+		}
+	}
+	return "", false
+}
+
+// runSelfUpdate реализует подкоманду self-update: находит последний релиз,
+// скачивает архив для текущей платформы, сверяет его контрольную сумму
+// с checksums.txt и заменяет запущенный исполняемый файл.
+func runSelfUpdate(args []string) {
+	fs := flag.NewFlagSet("self-update", flag.ExitOnError)
+	check, force, api := selfUpdateFlags(fs)
+	fs.Usage = commandUsage(fs, selfUpdateSynopsis)
+	lang := langFlag(fs)
+	fs.Parse(args)
+	setLocale(*lang)
+
+	client := newGitHubClient(*api, "")
+	var rel release
+	if err := getJSON(client.get, "/repos/"+releaseRepo+"/releases/latest", &rel); err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+		os.Exit(1)
+	}
+
+	fmt.Printf(tr("Текущая версия: %s, последний релиз: %s\n"), version, rel.Tag)
+	if rel.Tag == version && !*force {
+		fmt.Println(tr("Обновление не требуется."))
+		return
+	}
+	if *check {
+		return
+	}
+
+	if err := selfUpdate(client, &rel); err != nil {
+		fmt.Fprintf(os.Stderr, tr("ошибка: %v\n"), err)
+		os.Exit(1)
+	}
+	fmt.Printf(tr("Утилита обновлена до %s.\n"), rel.Tag)
+}
+
+// selfUpdate скачивает и проверяет архив релиза rel и устанавливает
+// из него исполняемый файл на место текущего.
+func selfUpdate(client *githubClient, rel *release) error {
+	name := fmt.Sprintf("loc_counter_%s_%s_%s.tar.gz", rel.Tag, runtime.GOOS, runtime.GOARCH)
+	binary := "loc_counter"
+	if runtime.GOOS == "windows" {
+		name = strings.TrimSuffix(name, ".tar.gz") + ".zip"
+		binary += ".exe"
+	}
+
+	archiveURL, ok := rel.asset(name)
+	if !ok {
+		return fmt.Errorf(tr("в релизе %s нет сборки для %s/%s"), rel.Tag, runtime.GOOS, runtime.GOARCH)
+	}
+	sumsURL, ok := rel.asset(checksumsAsset)
+	if !ok {
+		return fmt.Errorf(tr("в релизе %s нет %s: обновление без проверки контрольной суммы не выполняется"), rel.Tag, checksumsAsset)
+	}
+
+	want, err := releaseChecksum(client, sumsURL, name)
+	if err != nil {
+		return err
+	}
+	archive, err := download(client, archiveURL)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(archive)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return fmt.Errorf(tr("%s: контрольная сумма не совпадает (ожидалась %s, получена %s)"), name, want, got)
+	}
+
+	var exe []byte
+	if strings.HasSuffix(name, ".zip") {
+		exe, err = unzipFile(archive, binary)
+	} else {
+		exe, err = untarFile(archive, binary)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return replaceExecutable(exe)
+}
+
+// releaseChecksum возвращает контрольную сумму файла name из checksums.txt.
+func releaseChecksum(client *githubClient, url, name string) (string, error) {
+	data, err := download(client, url)
+	if err != nil {
+		return "", err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// sha256sum помечает двоичный режим звёздочкой перед именем
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf(tr("%s: нет контрольной суммы для %s"), checksumsAsset, name)
+}
+
+// download скачивает файл целиком.
+func download(client *githubClient, url string) ([]byte, error) {
+	body, err := client.get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+// untarFile извлекает файл name из архива tar.gz.
+func untarFile(archive []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	tarReader := tar.NewReader(gz)
+	for {
+		hdr, err := tarReader.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf(tr("в архиве нет %s"), name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag == tar.TypeReg && filepath.Base(hdr.Name) == name {
+			return io.ReadAll(tarReader)
+		}
+	}
+}
+
+// unzipFile извлекает файл name из архива zip.
+func unzipFile(archive []byte, name string) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range zr.File {
+		if filepath.Base(f.Name) != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	return nil, fmt.Errorf(tr("в архиве нет %s"), name)
+}
+
+// replaceExecutable записывает новый исполняемый файл рядом с текущим
+// и переименовывает его на место текущего. Windows не даёт перезаписать
+// запущенный файл, но позволяет его переименовать, поэтому старая версия
+// сначала откладывается в .old.
+func replaceExecutable(data []byte) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+
+	tmp := exe + ".new"
+	if err := os.WriteFile(tmp, data, 0o755); err != nil {
+		return err
+	}
+	if runtime.GOOS == "windows" {
+		old := exe + ".old"
+		os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	if err := os.Rename(tmp, exe); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
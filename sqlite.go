@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// sqliteSchema — таблицы базы истории запусков. Каждый запуск дописывает
+// строку в runs и свои строки в files и languages, а с --todos — метки
+// в комментариях в todos.
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS runs (
+	id INTEGER PRIMARY KEY,
+	started_at TEXT NOT NULL,
+	root TEXT,
+	files INTEGER NOT NULL,
+	lines INTEGER NOT NULL,
+	incomplete INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS files (
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	path TEXT NOT NULL,
+	language TEXT NOT NULL,
+	lines INTEGER NOT NULL,
+	comments INTEGER NOT NULL,
+	blanks INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS languages (
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	language TEXT NOT NULL,
+	files INTEGER NOT NULL,
+	lines INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS todos (
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	path TEXT NOT NULL,
+	line INTEGER NOT NULL,
+	marker TEXT NOT NULL,
+	text TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS files_run ON files(run_id);
+CREATE INDEX IF NOT EXISTS languages_run ON languages(run_id);
+CREATE INDEX IF NOT EXISTS todos_run ON todos(run_id);
+`
+
+// sqliteOutput возвращает вывод, дописывающий запуск в базу SQLite db.
+// База заполняется утилитой sqlite3: SQL передаётся ей на stdin одной
+// транзакцией, поэтому сборка обходится без cgo.
+func sqliteOutput(db string) func(*report, *options) {
+	return func(rep *report, opts *options) {
+		if err := writeSQLite(db, rep); err != nil {
+			fmt.Fprintf(os.Stderr, tr("ошибка: --format sqlite: %v\n"), err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, tr("Запуск сохранён в %s: файлов %d, строк %d.\n"), db, len(rep.files), rep.totalLines)
+	}
+}
+
+// writeSQLite записывает отчёт rep новым запуском в базу db.
+func writeSQLite(db string, rep *report) error {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		return errors.New(tr("не найдена утилита sqlite3 (установите её или выберите другой формат)"))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(sqliteSchema)
+	sb.WriteString("BEGIN;\n")
+	incomplete := 0
+	if rep.incomplete {
+		incomplete = 1
+	}
+	fmt.Fprintf(&sb, "INSERT INTO runs (started_at, root, files, lines, incomplete) VALUES (%s, %s, %d, %d, %d);\n",
+		sqlQuote(time.Now().UTC().Format(time.RFC3339)), sqlQuote(rep.root), len(rep.files), rep.totalLines, incomplete)
+
+	// Запуск только что вставлен в этой транзакции — у него наибольший id
+	const runID = "(SELECT max(id) FROM runs)"
+	for _, f := range rep.files {
+		fmt.Fprintf(&sb, "INSERT INTO files VALUES (%s, %s, %s, %d, %d, %d);\n",
+			runID, sqlQuote(f.path), sqlQuote(f.lang), f.lines, f.comments, f.blanks)
+		for _, t := range f.todos {
+			fmt.Fprintf(&sb, "INSERT INTO todos VALUES (%s, %s, %d, %s, %s);\n",
+				runID, sqlQuote(f.path), t.Line, sqlQuote(t.Marker), sqlQuote(t.Text))
+		}
+	}
+	langs, totals := rep.byLanguage()
+	for _, lang := range langs {
+		fmt.Fprintf(&sb, "INSERT INTO languages VALUES (%s, %s, %d, %d);\n",
+			runID, sqlQuote(lang), totals[lang].files, totals[lang].lines)
+	}
+	sb.WriteString("COMMIT;\n")
+
+	cmd := exec.Command("sqlite3", "-bail", db)
+	cmd.Stdin = strings.NewReader(sb.String())
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("sqlite3: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// sqlQuote возвращает строковый литерал SQL.
+func sqlQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// lineStats — распределение размеров файлов в строках кода.
+type lineStats struct {
+	mean      float64
+	median    int
+	p95       int
+	max       int
+	maxPath   string
+	overLimit int // файлы больше порога --stats-large
+}
+
+// percentile возвращает перцентиль p (0–100) отсортированных значений
+// методом ближайшего ранга.
+func percentile(sorted []int, p float64) int {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// lineStats считает распределение размеров файлов отчёта и число файлов
+// больше large строк.
+func (r *report) lineStats(large int) lineStats {
+	var s lineStats
+	if len(r.files) == 0 {
+		return s
+	}
+	sizes := make([]int, 0, len(r.files))
+	for _, f := range r.files {
+		sizes = append(sizes, f.lines)
+		if f.lines > s.max || s.maxPath == "" {
+			s.max, s.maxPath = f.lines, f.path
+		}
+		if f.lines > large {
+			s.overLimit++
+		}
+	}
+	sort.Ints(sizes)
+	s.mean = float64(r.totalLines) / float64(len(sizes))
+	s.median = percentile(sizes, 50)
+	s.p95 = percentile(sizes, 95)
+	return s
+}
+
+// printStats выводит распределение размеров файлов: среднее, медиану,
+// 95-й перцентиль, самый большой файл и число файлов больше порога.
+// По изменению этих значений от сборки к сборке видно, растут ли файлы.
+func printStats(rep *report, large int) {
+	s := rep.lineStats(large)
+	rows := [][]string{
+		{tr("Среднее"), strconv.FormatFloat(s.mean, 'f', 1, 64)},
+		{tr("Медиана"), strconv.Itoa(s.median)},
+		{tr("95-й перцентиль"), strconv.Itoa(s.p95)},
+		{fmt.Sprintf(tr("Максимум (%s)"), s.maxPath), strconv.Itoa(s.max)},
+		{fmt.Sprintf(tr("Файлов больше %d строк"), large), strconv.Itoa(s.overLimit)},
+	}
+	printTable([]string{tr("Размер файлов в строках кода"), tr("Значение")}, rows, nil)
+}
@@ -0,0 +1,97 @@
+package main
+
+// styleCounter собирает статистику оформления содержимого, прочитанного
+// через io.TeeReader: длину самой длинной строки, стиль отступов и число
+// строк с пробелами в конце. Как и textCounter, работает по байтам
+// и хранит состояние текущей строки между вызовами Write.
+type styleCounter struct {
+	longest  int // самая длинная строка в символах
+	trailing int // строки с пробелами или табуляцией в конце
+	tabs     int // строки с отступом табуляцией
+	spaces   int // строки с отступом пробелами
+
+	chars   int  // символы текущей строки
+	indent  byte // первый символ отступа текущей строки (0 — без отступа)
+	leading bool // текущая строка пока состоит из отступа
+	lastWS  bool // последний символ текущей строки — пробел или табуляция
+}
+
+func newStyleCounter() *styleCounter {
+	return &styleCounter{leading: true}
+}
+
+func (s *styleCounter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		switch b {
+		case '\n':
+			s.endLine()
+			continue
+		case '\r':
+			// Окончание строки CRLF не считается ни символом, ни пробелом
+			continue
+		case ' ', '\t':
+			if s.chars == 0 {
+				s.indent = b
+			}
+			s.lastWS = true
+		default:
+			s.leading = false
+			s.lastWS = false
+		}
+		if b&0xC0 != 0x80 {
+			s.chars++
+		}
+	}
+	return len(p), nil
+}
+
+// endLine завершает текущую строку.
+func (s *styleCounter) endLine() {
+	s.longest = max(s.longest, s.chars)
+	if s.lastWS {
+		s.trailing++
+	}
+	// Строка из одних пробелов — не отступ кода
+	if !s.leading {
+		switch s.indent {
+		case '\t':
+			s.tabs++
+		case ' ':
+			s.spaces++
+		}
+	}
+	s.chars, s.indent, s.leading, s.lastWS = 0, 0, true, false
+}
+
+// indentStyle возвращает стиль отступов: tabs, spaces, mixed или пустую
+// строку, если строк с отступом нет.
+func (s *styleCounter) indentStyle() string {
+	switch {
+	case s.tabs > 0 && s.spaces > 0:
+		return "mixed"
+	case s.tabs > 0:
+		return "tabs"
+	case s.spaces > 0:
+		return "spaces"
+	}
+	return ""
+}
+
+// record записывает статистику в counts. Нулевой счётчик ничего не делает.
+func (s *styleCounter) record(counts *fileCounts) {
+	if s == nil {
+		return
+	}
+	if s.chars > 0 || s.lastWS {
+		s.endLine()
+	}
+	counts.Longest, counts.Indent, counts.Trailing = s.longest, s.indentStyle(), s.trailing
+}
+
+// indentLabels — подписи стилей отступов в таблице.
+var indentLabels = map[string]string{
+	"tabs":   "табуляция",
+	"spaces": "пробелы",
+	"mixed":  "смешанные",
+	"":       "-",
+}
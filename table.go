@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tableStyle — оформление таблиц отчёта (--style): plain — столбцы
+// через пробелы и черты из дефисов, box — рамка из символов Unicode,
+// compact — столбцы через один пробел без черт.
+var tableStyle = "plain"
+
+// checkTableStyle проверяет значение --style.
+func checkTableStyle(style string) error {
+	switch style {
+	case "plain", "box", "compact":
+		return nil
+	}
+	return fmt.Errorf(tr("неизвестный стиль таблицы %q (ожидается plain, box или compact)"), style)
+}
+
+// printTable выводит выровненную таблицу: первый столбец по левому краю,
+// остальные (числовые) — по правому. Строка итогов отделяется чертой;
+// при totals == nil она не выводится, иначе выделяется полужирным, если
+// включены цвета. После таблицы печатается пустая строка.
+func printTable(headers []string, rows [][]string, totals []string) {
+	numeric := make([]bool, len(headers))
+	for i := 1; i < len(numeric); i++ {
+		numeric[i] = true
+	}
+	renderTable(headers, rows, totals, numeric)
+	fmt.Println()
+}
+
+// renderTable выводит таблицу в стиле tableStyle. Столбцы, отмеченные
+// в numeric, выравниваются по правому краю, остальные — по левому.
+func renderTable(headers []string, rows [][]string, totals []string, numeric []bool) {
+	all := append([][]string{headers}, rows...)
+	if totals != nil {
+		all = append(all, totals)
+	}
+
+	widths := make([]int, len(headers))
+	for _, row := range all {
+		for i, cell := range row {
+			widths[i] = max(widths[i], visibleLen(cell))
+		}
+	}
+
+	sep := "  "
+	if tableStyle == "compact" {
+		sep = " "
+	}
+	printRow := func(row []string) {
+		var sb strings.Builder
+		if tableStyle == "box" {
+			sb.WriteString("│ ")
+		}
+		for i, cell := range row {
+			pad := strings.Repeat(" ", widths[i]-visibleLen(cell))
+			switch {
+			case i > 0 && tableStyle == "box":
+				sb.WriteString(" │ ")
+			case i > 0:
+				sb.WriteString(sep)
+			}
+			if numeric[i] {
+				sb.WriteString(pad + cell)
+			} else {
+				sb.WriteString(cell + pad)
+			}
+		}
+		if tableStyle == "box" {
+			sb.WriteString(" │")
+		}
+		fmt.Println(sb.String())
+	}
+	rule := func(left, mid, right string) {
+		switch tableStyle {
+		case "box":
+			parts := make([]string, len(widths))
+			for i, w := range widths {
+				parts[i] = strings.Repeat("─", w+2)
+			}
+			fmt.Println(left + strings.Join(parts, mid) + right)
+		case "plain":
+			lineWidth := 0
+			for _, w := range widths {
+				lineWidth += w + 2
+			}
+			fmt.Println(strings.Repeat("-", lineWidth))
+		}
+	}
+
+	if tableStyle == "box" {
+		rule("┌", "┬", "┐")
+	}
+	printRow(headers)
+	rule("├", "┼", "┤")
+	for _, row := range rows {
+		printRow(row)
+	}
+	if totals != nil {
+		rule("├", "┼", "┤")
+		printRow(paintRow(ansiBold, totals))
+	}
+	if tableStyle == "box" {
+		rule("└", "┴", "┘")
+	}
+}
+
+// printMarkdownTable выводит таблицу в разметке GitHub Flavored Markdown:
+// числовые столбцы выравниваются по правому краю, строка итогов выделяется
+// полужирным. После таблицы печатается пустая строка.
+func printMarkdownTable(headers []string, rows [][]string, totals []string) {
+	printRow := func(row []string, bold bool) {
+		var sb strings.Builder
+		sb.WriteString("|")
+		for _, cell := range row {
+			cell = strings.ReplaceAll(cell, "|", `\|`)
+			if bold && cell != "" {
+				cell = "**" + cell + "**"
+			}
+			sb.WriteString(" " + cell + " |")
+		}
+		fmt.Println(sb.String())
+	}
+
+	printRow(headers, false)
+	// По правому краю выравниваются столбцы, где все значения — числа
+	align := make([]string, len(headers))
+	for i := range align {
+		align[i] = "---:"
+		for _, row := range rows {
+			if _, err := strconv.ParseFloat(strings.TrimSuffix(row[i], "%"), 64); err != nil && row[i] != "" {
+				align[i] = "---"
+				break
+			}
+		}
+	}
+	fmt.Println("|" + strings.Join(align, "|") + "|")
+	for _, row := range rows {
+		printRow(row, false)
+	}
+	if totals != nil {
+		printRow(totals, true)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs — функции, доступные в шаблонах --template помимо встроенных.
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"pad": func(width int, s string) string {
+		return fmt.Sprintf("%-*s", width, s)
+	},
+	"lpad": func(width int, v any) string {
+		return fmt.Sprintf("%*v", width, v)
+	},
+	"percent": func(part, total int) string {
+		return formatShare(part, total)
+	},
+}
+
+// loadTemplate разбирает шаблон --template. Если значение — путь
+// к существующему файлу, шаблон читается из него, иначе значение
+// само считается текстом шаблона.
+func loadTemplate(s string) (*template.Template, error) {
+	text := s
+	if info, err := os.Stat(s); err == nil && info.Mode().IsRegular() {
+		data, err := os.ReadFile(s)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	return template.New("template").Funcs(templateFuncs).Parse(text)
+}
+
+// templateOutput возвращает вывод отчёта по шаблону t. Шаблону передаётся
+// та же структура, что выводится в --format json: .Files, .Extensions,
+// .Languages, .Total, .Unknown, .Errors и .Incomplete; поля записей
+// называются как в Go (.Path, .Language, .Lines и т. д.).
+func templateOutput(t *template.Template) func(*report, *options) {
+	return func(rep *report, opts *options) {
+		if err := t.Execute(os.Stdout, newJSONReport(rep, opts)); err != nil {
+			fmt.Fprintf(os.Stderr, tr("ошибка: --template: %v\n"), err)
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+
+package main
+
+import "os"
+
+// consoleHeight на прочих платформах неизвестна.
+func consoleHeight(_ *os.File) int {
+	return 0
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"os"
+	"unsafe"
+)
+
+var procGetConsoleScreenBufferInfo = kernel32.NewProc("GetConsoleScreenBufferInfo")
+
+// consoleScreenBufferInfo соответствует CONSOLE_SCREEN_BUFFER_INFO.
+type consoleScreenBufferInfo struct {
+	size, cursorPosition     [2]int16
+	attributes               uint16
+	left, top, right, bottom int16
+	maximumWindowSize        [2]int16
+}
+
+// consoleHeight возвращает высоту окна консоли в строках (0 — неизвестна).
+func consoleHeight(f *os.File) int {
+	var info consoleScreenBufferInfo
+	r, _, _ := procGetConsoleScreenBufferInfo.Call(f.Fd(), uintptr(unsafe.Pointer(&info)))
+	if r == 0 {
+		return 0
+	}
+	return int(info.bottom-info.top) + 1
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+)
+
+// textCounter считает байты, символы и приблизительное число токенов
+// содержимого, прочитанного через io.TeeReader. Содержимое может приходить
+// произвольными кусками, поэтому состояние хранится между вызовами Write.
+type textCounter struct {
+	bytes, chars, tokens int
+	word                 int // байты незавершённого слова
+}
+
+func (t *textCounter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		t.bytes++
+		// Символ UTF-8 начинается с любого байта, кроме продолжающего 10xxxxxx
+		if b&0xC0 != 0x80 {
+			t.chars++
+		}
+		switch {
+		case b >= 0x80 || b == '_' || '0' <= b && b <= '9' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z':
+			t.word++
+		case b == ' ' || b == '\t' || b == '\n' || b == '\r':
+			t.endWord()
+		default:
+			// Знаки препинания и операторы — отдельные токены
+			t.endWord()
+			t.tokens++
+		}
+	}
+	return len(p), nil
+}
+
+// endWord учитывает завершённое слово. Токенизаторы языковых моделей
+// делят длинные идентификаторы на части, в среднем около 4 байт
+// на токен, — эта оценка и используется.
+func (t *textCounter) endWord() {
+	if t.word > 0 {
+		t.tokens += (t.word + 3) / 4
+		t.word = 0
+	}
+}
+
+// record записывает статистику в counts. Нулевой счётчик ничего не делает.
+func (t *textCounter) record(counts *fileCounts) {
+	if t == nil {
+		return
+	}
+	t.endWord()
+	counts.Bytes, counts.Chars, counts.Tokens = t.bytes, t.chars, t.tokens
+}
+
+// lineWriter разбивает содержимое, прочитанное через io.TeeReader,
+// на строки и передаёт их по одной в fn. Незавершённая строка хранится
+// между вызовами Write; flush передаёт последнюю строку без перевода строки.
+type lineWriter struct {
+	fn      func(line string)
+	partial []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			break
+		}
+		w.partial = append(w.partial, p[:i]...)
+		w.fn(strings.TrimSuffix(string(w.partial), "\r"))
+		w.partial = w.partial[:0]
+		p = p[i+1:]
+	}
+	w.partial = append(w.partial, p...)
+	return n, nil
+}
+
+func (w *lineWriter) flush() {
+	if len(w.partial) > 0 {
+		w.fn(strings.TrimSuffix(string(w.partial), "\r"))
+		w.partial = nil
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// defaultTodoMarkers — метки, которые --todos ищет всегда; --todo-markers
+// добавляет к ним свои.
+var defaultTodoMarkers = []string{"TODO", "FIXME", "HACK"}
+
+// todoItem — метка в комментарии: номер строки, сама метка и текст
+// комментария начиная с неё.
+type todoItem struct {
+	Line   int    `json:"line"`
+	Marker string `json:"marker"`
+	Text   string `json:"text"`
+}
+
+// todoMarkers возвращает метки --todos без повторов: стандартные
+// и добавленные --todo-markers.
+func (o *options) todoMarkers() []string {
+	seen := make(map[string]bool)
+	var markers []string
+	for _, m := range append(append([]string(nil), defaultTodoMarkers...), o.todoExtra...) {
+		if !seen[m] {
+			seen[m] = true
+			markers = append(markers, m)
+		}
+	}
+	return markers
+}
+
+// todoPattern возвращает выражение, находящее метки целым словом,
+// или nil без --todos.
+func (o *options) todoPattern() *regexp.Regexp {
+	if !o.todos {
+		return nil
+	}
+	markers := o.todoMarkers()
+	for i, m := range markers {
+		markers[i] = regexp.QuoteMeta(m)
+	}
+	return regexp.MustCompile(`\b(` + strings.Join(markers, "|") + `)\b`)
+}
+
+// todoCounter ищет метки в комментариях содержимого, прочитанного через
+// io.TeeReader, — как textCounter. Строки классифицируются собственным
+// lineClassifier, поэтому подсчёт строк кода не меняется.
+type todoCounter struct {
+	lineWriter
+	re         *regexp.Regexp
+	classifier lineClassifier
+	document   bool // документ со встроенными блоками: метки ищутся во всём тексте
+	line       int
+	items      []todoItem
+}
+
+func newTodoCounter(re *regexp.Regexp, cfg LangConfig) *todoCounter {
+	t := &todoCounter{re: re, classifier: lineClassifier{cfg: cfg}, document: cfg.Embedded != nil}
+	t.fn = t.scanLine
+	return t
+}
+
+// scanLine находит метки в комментарии очередной строки.
+func (t *todoCounter) scanLine(line string) {
+	t.line++
+	comment := line
+	if !t.document {
+		t.classifier.classify(line)
+		comment = t.classifier.commentText(line)
+	}
+	for _, loc := range t.re.FindAllStringSubmatchIndex(comment, -1) {
+		text := strings.TrimSpace(comment[loc[2]:])
+		if end := t.classifier.cfg.MultiEnd; end != "" {
+			text = strings.TrimSpace(strings.TrimSuffix(text, end))
+		}
+		t.items = append(t.items, todoItem{t.line, comment[loc[2]:loc[3]], text})
+	}
+}
+
+// record записывает найденные метки в counts. Нулевой счётчик ничего
+// не делает.
+func (t *todoCounter) record(counts *fileCounts) {
+	if t == nil {
+		return
+	}
+	t.flush()
+	counts.Todos = t.items
+}
+
+// todoTally возвращает число меток каждого вида в порядке markers.
+func todoTally(markers []string, totals map[string]int) []string {
+	parts := make([]string, 0, len(markers))
+	for _, m := range markers {
+		parts = append(parts, fmt.Sprintf("%s %d", m, totals[m]))
+	}
+	return parts
+}
+
+// totalTodos возвращает общее число меток в комментариях.
+func (r *report) totalTodos() int {
+	total := 0
+	for _, n := range r.todos {
+		total += n
+	}
+	return total
+}
+
+// todoTotals возвращает число меток каждого вида, включая не найденные, —
+// для машиночитаемых форматов.
+func (r *report) todoTotals(opts *options) map[string]int {
+	totals := make(map[string]int)
+	for _, m := range opts.todoMarkers() {
+		totals[m] = r.todos[m]
+	}
+	return totals
+}
+
+// printTodos выводит итог по меткам в комментариях и, с --todos-list,
+// сами метки в виде «файл:строка: текст», понятном редакторам.
+func printTodos(rep *report, opts *options) {
+	fmt.Printf(tr("Метки в комментариях: %d (%s)\n"), rep.totalTodos(), strings.Join(todoTally(opts.todoMarkers(), rep.todos), ", "))
+	if opts.todosList {
+		for _, f := range rep.files {
+			for _, it := range f.todos {
+				fmt.Printf("%s:%d: %s\n", f.path, it.Line, it.Text)
+			}
+		}
+	}
+	fmt.Println()
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// treeNode — директория в дереве --tree с итогами по всем вложенным файлам.
+type treeNode struct {
+	name         string
+	files, lines int
+	children     map[string]*treeNode
+}
+
+// child возвращает поддиректорию name, создавая её при необходимости.
+func (n *treeNode) child(name string) *treeNode {
+	if n.children == nil {
+		n.children = make(map[string]*treeNode)
+	}
+	c, ok := n.children[name]
+	if !ok {
+		c = &treeNode{name: name}
+		n.children[name] = c
+	}
+	return c
+}
+
+// rootName возвращает корень обхода в том виде, в каком он входит в пути
+// файлов отчёта; у файлов не с диска корень — «.».
+func (r *report) rootName() string {
+	if r.root == "" {
+		return "."
+	}
+	return path.Clean(displayPath(r.root))
+}
+
+// relDirs возвращает директории пути файла p относительно корня обхода.
+func (r *report) relDirs(p string) []string {
+	if root := r.rootName(); root != "." {
+		p = strings.TrimPrefix(p, strings.TrimSuffix(root, "/")+"/")
+	}
+	dirs := strings.Split(p, "/")
+	return dirs[:len(dirs)-1]
+}
+
+// buildTree собирает дерево директорий отчёта.
+func buildTree(rep *report) *treeNode {
+	root := &treeNode{name: rep.rootName()}
+	for _, f := range rep.files {
+		dirs := rep.relDirs(f.path)
+
+		node := root
+		node.files++
+		node.lines += f.lines
+		for _, dir := range dirs {
+			node = node.child(dir)
+			node.files++
+			node.lines += f.lines
+		}
+	}
+	return root
+}
+
+// printTree выводит дерево директорий с итогами в каждом узле, как du:
+// поддиректории упорядочены по убыванию строк, чтобы сразу было видно,
+// какие поддеревья составляют основную часть кода.
+func printTree(rep *report) {
+	var rows [][]string
+	var walk func(n *treeNode, prefix string)
+	walk = func(n *treeNode, prefix string) {
+		children := make([]*treeNode, 0, len(n.children))
+		for _, c := range n.children {
+			children = append(children, c)
+		}
+		sort.Slice(children, func(i, j int) bool {
+			if children[i].lines != children[j].lines {
+				return children[i].lines > children[j].lines
+			}
+			return children[i].name < children[j].name
+		})
+
+		for i, c := range children {
+			branch, next := "├── ", "│   "
+			if i == len(children)-1 {
+				branch, next = "└── ", "    "
+			}
+			rows = append(rows, []string{prefix + branch + c.name, strconv.Itoa(c.files), strconv.Itoa(c.lines)})
+			walk(c, prefix+next)
+		}
+	}
+
+	root := buildTree(rep)
+	rows = append(rows, []string{root.name, strconv.Itoa(root.files), strconv.Itoa(root.lines)})
+	walk(root, "")
+	printTable([]string{tr("Директория"), tr("Файлы"), tr("Строки")}, rows, nil)
+}
+
+// dirDepth — значение флага --by-dir[=N]: глубина сводки по директориям.
+// Флаг без значения означает глубину 1.
+type dirDepth int
+
+func (d *dirDepth) String() string   { return strconv.Itoa(int(*d)) }
+func (d *dirDepth) IsBoolFlag() bool { return true }
+func (d *dirDepth) Set(v string) error {
+	switch v {
+	case "true":
+		*d = 1
+		return nil
+	case "false":
+		*d = 0
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return fmt.Errorf(tr("ожидается глубина — целое число от 1, получено %q"), v)
+	}
+	*d = dirDepth(n)
+	return nil
+}
+
+// byDir возвращает итоги по директориям до глубины depth от корня обхода.
+// Файлы мельче этой глубины учитываются в своей директории, файлы в корне —
+// в «.».
+func (r *report) byDir(depth int) map[string]*subtotal {
+	totals := make(map[string]*subtotal)
+	for _, f := range r.files {
+		dirs := r.relDirs(f.path)
+		key := "."
+		if len(dirs) > 0 {
+			key = strings.Join(dirs[:min(depth, len(dirs))], "/") + "/"
+		}
+		r.addSubtotal(totals, key, f.lines)
+	}
+	return totals
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// weightTable задаёт веса языков для нормированного подсчёта: строка кода
+// на языке умножается на его вес. Языки без веса учитываются с весом 1.
+// Ключи хранятся в нижнем регистре.
+type weightTable map[string]float64
+
+// weightTable как флаг принимает пары «Язык=вес» через запятую
+// или отдельными флагами: --weights Python=1.6,Java=1.
+func (t weightTable) String() string {
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+strconv.FormatFloat(t[k], 'g', -1, 64))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (t weightTable) Set(v string) error {
+	for _, part := range strings.Split(v, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			if err := t.parse(part); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parse разбирает одну пару «Язык=вес».
+func (t weightTable) parse(pair string) error {
+	lang, value, ok := strings.Cut(pair, "=")
+	if !ok {
+		return fmt.Errorf(tr("ожидается «Язык=вес», получено %q"), pair)
+	}
+	w, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || w < 0 {
+		return fmt.Errorf(tr("некорректный вес %q для языка %q"), value, lang)
+	}
+	t[strings.ToLower(strings.TrimSpace(lang))] = w
+	return nil
+}
+
+// load читает веса из файла: по паре «Язык=вес» на строку,
+// пустые строки и строки, начинающиеся с #, пропускаются.
+func (t weightTable) load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := t.parse(line); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// weight возвращает вес языка (1, если он не задан).
+func (t weightTable) weight(lang string) float64 {
+	if w, ok := t[strings.ToLower(lang)]; ok {
+		return w
+	}
+	return 1
+}
+
+// normalized возвращает нормированное число строк отчёта.
+func (t weightTable) normalized(rep *report) float64 {
+	sum := 0.0
+	for _, f := range rep.files {
+		if f.parts != nil {
+			for lang, lines := range f.parts {
+				sum += float64(lines) * t.weight(lang)
+			}
+			continue
+		}
+		sum += float64(f.lines) * t.weight(f.lang)
+	}
+	return sum
+}